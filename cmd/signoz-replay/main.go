@@ -0,0 +1,65 @@
+// Command signoz-replay sends the batches stored in a dead-letter directory to SigNoz again.
+//
+//	signoz-replay -dir /var/lib/logspout/deadletter 'signoz+otlp://otel-collector:4318?compression=gzip'
+//
+// The route takes the same form and options as the logspout route the batches came from.
+// SIGNOZ_* environment variables are honoured as well. The newest file of each route is left
+// alone, as a running logspout may still write to it; -all replays it too once logspout is
+// stopped.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gliderlabs/logspout/router"
+	"github.com/pavanputhra/logspout-signoz/signoz"
+)
+
+func main() {
+	dir := flag.String("dir", os.Getenv("SIGNOZ_DEADLETTER_DIR"), "dead-letter directory to replay")
+	keep := flag.Bool("keep", false, "keep dead-letter files after they were replayed")
+	all := flag.Bool("all", false, "also replay the newest file of each route, only while logspout is stopped")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-dir DIR] [-keep] [-all] ROUTE\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *dir == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	route, err := parseRoute(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	replayed, err := signoz.ReplayDeadLetters(*dir, route, *keep, *all)
+	log.Printf("Replayed %d log records", replayed)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseRoute builds a route from a URI the way logspout does for its route arguments.
+func parseRoute(uri string) (*router.Route, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	route := &router.Route{
+		Adapter: u.Scheme,
+		Address: u.Host,
+		Options: make(map[string]string),
+	}
+	for key, values := range u.Query() {
+		if !strings.HasPrefix(key, "filter.") {
+			route.Options[key] = values[0]
+		}
+	}
+	return route, nil
+}
@@ -0,0 +1,155 @@
+package signoz
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Common parts of the access log formats. Fields named duration_<unit> hold the request
+// duration and are converted to seconds; "-" stands for a missing value.
+const (
+	clfPattern      = `^%{IPORHOST:client.address} %{NOTSPACE:ident} %{NOTSPACE:user.name} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:http.request.method} %{NOTSPACE:url.path}(?: %{NOTSPACE:network.protocol})?|%{DATA:request})" %{INT:http.response.status_code} %{NOTSPACE:http.response.body.size}`
+	combinedPattern = clfPattern + ` "%{DATA:http.request.header.referer}" "%{DATA:user_agent.original}"`
+)
+
+// accessLogFormats are the parsers for the access logs of common proxies and web servers. They
+// extract OpenTelemetry semantic convention HTTP attributes and derive the severity from the
+// response status.
+var accessLogFormats = map[string]lineParser{
+	"apache-common":   mustAccessLogParser(clfPattern),
+	"apache-combined": mustAccessLogParser(combinedPattern),
+	"nginx-combined":  mustAccessLogParser(combinedPattern),
+	"traefik":         mustAccessLogParser(combinedPattern + ` %{INT:traefik.requests} "%{DATA:traefik.router}" "%{DATA:traefik.service.url}" %{INT:duration_ms}ms`),
+	"envoy": mustAccessLogParser(`^\[%{TIMESTAMP_ISO8601:timestamp}\] "%{WORD:http.request.method} %{NOTSPACE:url.path} %{NOTSPACE:network.protocol}" %{INT:http.response.status_code} %{NOTSPACE:envoy.response_flags}` +
+		`(?: %{NOTSPACE:envoy.response_code_details} %{NOTSPACE:envoy.connection_termination_details} "%{DATA:envoy.upstream_transport_failure_reason}")?` +
+		` %{INT:http.request.body.size} %{INT:http.response.body.size} %{INT:duration_ms} %{NOTSPACE:envoy.upstream_service_time}` +
+		` "%{DATA:client.address}" "%{DATA:user_agent.original}" "%{DATA:http.request.id}" "%{DATA:server.address}" "%{DATA:envoy.upstream_host}"`),
+	"caddy": caddyParser{},
+}
+
+// accessLogParser is a regexParser whose fields are completed by accessLogFields.
+type accessLogParser struct {
+	*regexParser
+}
+
+func mustAccessLogParser(pattern string) accessLogParser {
+	p, err := newRegexParser(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return accessLogParser{p}
+}
+
+func (p accessLogParser) parse(data string, timestamps *timestampParser, logMessage *LogMessage) bool {
+	if !p.regexParser.parse(data, timestamps, logMessage) {
+		return false
+	}
+	accessLogFields(logMessage)
+	return true
+}
+
+// accessLogFields turns the raw fields extracted from an access log into semantic convention
+// attributes and sets the severity from the status class: 5xx is an error, 4xx a warning.
+func accessLogFields(logMessage *LogMessage) {
+	attributes := logMessage.Attributes
+	for key, value := range attributes {
+		if value == "-" {
+			delete(attributes, key)
+		}
+	}
+	delete(attributes, "ident")
+
+	for unit, scale := range map[string]float64{"duration_s": 1, "duration_ms": 1e-3, "duration_us": 1e-6} {
+		if value, exists := attributes[unit]; exists {
+			delete(attributes, unit)
+			if duration, err := strconv.ParseFloat(value, 64); err == nil {
+				attributes["http.server.request.duration"] = strconv.FormatFloat(duration*scale, 'f', -1, 64)
+			}
+		}
+	}
+	if target, exists := attributes["url.path"]; exists {
+		if path, query, found := strings.Cut(target, "?"); found {
+			attributes["url.path"], attributes["url.query"] = path, query
+		}
+	}
+	if protocol, exists := attributes["network.protocol"]; exists {
+		delete(attributes, "network.protocol")
+		if name, version, found := strings.Cut(protocol, "/"); found {
+			attributes["network.protocol.name"] = strings.ToLower(name)
+			attributes["network.protocol.version"] = version
+		}
+	}
+	if client, exists := attributes["client.address"]; exists {
+		// X-Forwarded-For lists the original client first.
+		client, _, _ = strings.Cut(client, ",")
+		attributes["client.address"] = strings.TrimSpace(client)
+	}
+
+	status, _ := strconv.Atoi(attributes["http.response.status_code"])
+	level := "INFO"
+	switch {
+	case status >= 500:
+		level = "ERROR"
+	case status >= 400:
+		level = "WARN"
+	}
+	logMessage.SeverityText = strings.ToLower(level)
+	logMessage.SeverityNumber = logLevelMap[level]
+}
+
+// caddyParser reads the JSON access logs of Caddy.
+type caddyParser struct{}
+
+type caddyAccessLog struct {
+	Timestamp json.Number `json:"ts"`
+	Logger    string      `json:"logger"`
+	Request   struct {
+		RemoteIP string              `json:"remote_ip"`
+		ClientIP string              `json:"client_ip"`
+		Proto    string              `json:"proto"`
+		Method   string              `json:"method"`
+		Host     string              `json:"host"`
+		URI      string              `json:"uri"`
+		Headers  map[string][]string `json:"headers"`
+	} `json:"request"`
+	Duration float64 `json:"duration"`
+	Size     int64   `json:"size"`
+	Status   int     `json:"status"`
+}
+
+func (caddyParser) parse(data string, timestamps *timestampParser, logMessage *LogMessage) bool {
+	var entry caddyAccessLog
+	if err := json.Unmarshal([]byte(data), &entry); err != nil || entry.Request.Method == "" || entry.Status == 0 {
+		return false
+	}
+
+	attributes := logMessage.Attributes
+	attributes["http.request.method"] = entry.Request.Method
+	attributes["url.path"] = entry.Request.URI
+	attributes["network.protocol"] = entry.Request.Proto
+	attributes["http.response.status_code"] = strconv.Itoa(entry.Status)
+	attributes["http.response.body.size"] = strconv.FormatInt(entry.Size, 10)
+	attributes["duration_s"] = strconv.FormatFloat(entry.Duration, 'f', -1, 64)
+	attributes["server.address"] = entry.Request.Host
+	attributes["client.address"] = entry.Request.ClientIP
+	if attributes["client.address"] == "" {
+		attributes["client.address"] = entry.Request.RemoteIP
+	}
+	if userAgent := entry.Request.Headers["User-Agent"]; len(userAgent) > 0 {
+		attributes["user_agent.original"] = userAgent[0]
+	}
+	if entry.Logger != "" {
+		attributes["caddy.logger"] = entry.Logger
+	}
+	for key, value := range attributes {
+		if value == "" {
+			delete(attributes, key)
+		}
+	}
+	if timestamp, ok := timestamps.parse(entry.Timestamp.String()); ok {
+		logMessage.Timestamp = timestamp.UnixNano()
+	}
+	accessLogFields(logMessage)
+	return true
+}
@@ -0,0 +1,143 @@
+package signoz
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccessLogFormats(t *testing.T) {
+	tests := []struct {
+		format        string
+		data          string
+		wantTimestamp int64
+		wantSeverity  string
+		want          map[string]string
+	}{
+		{
+			"nginx-combined",
+			`172.17.0.1 - alice [01/May/2024:10:00:00 +0000] "GET /api/items?page=2 HTTP/1.1" 200 512 "https://example.com/" "curl/8.4.0"`,
+			time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixNano(), "info",
+			map[string]string{
+				"client.address":              "172.17.0.1",
+				"user.name":                   "alice",
+				"http.request.method":         "GET",
+				"url.path":                    "/api/items",
+				"url.query":                   "page=2",
+				"network.protocol.name":       "http",
+				"network.protocol.version":    "1.1",
+				"http.response.status_code":   "200",
+				"http.response.body.size":     "512",
+				"http.request.header.referer": "https://example.com/",
+				"user_agent.original":         "curl/8.4.0",
+			},
+		},
+		{
+			"apache-common",
+			`10.0.0.1 - - [01/May/2024:12:00:00 +0200] "POST /login HTTP/1.0" 503 -`,
+			time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixNano(), "error",
+			map[string]string{
+				"client.address":            "10.0.0.1",
+				"http.request.method":       "POST",
+				"url.path":                  "/login",
+				"network.protocol.name":     "http",
+				"network.protocol.version":  "1.0",
+				"http.response.status_code": "503",
+			},
+		},
+		{
+			"traefik",
+			`192.168.1.5 - - [01/May/2024:10:00:00 +0000] "GET /missing HTTP/2.0" 404 19 "-" "Mozilla/5.0" 42 "web@docker" "http://172.18.0.3:80" 3ms`,
+			time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixNano(), "warn",
+			map[string]string{
+				"client.address":               "192.168.1.5",
+				"http.request.method":          "GET",
+				"url.path":                     "/missing",
+				"network.protocol.name":        "http",
+				"network.protocol.version":     "2.0",
+				"http.response.status_code":    "404",
+				"http.response.body.size":      "19",
+				"user_agent.original":          "Mozilla/5.0",
+				"traefik.requests":             "42",
+				"traefik.router":               "web@docker",
+				"traefik.service.url":          "http://172.18.0.3:80",
+				"http.server.request.duration": "0.003",
+			},
+		},
+		{
+			"envoy",
+			`[2024-05-01T10:00:00.310Z] "POST /api/v1/locations HTTP/2" 204 - 154 0 226 100 "10.0.35.28, 10.0.0.1" "nsq2http" "cc21d9b0-cf5c-432b-8c7e-98aeb7988cd2" "locations" "tcp://10.0.2.1:80"`,
+			time.Date(2024, 5, 1, 10, 0, 0, 310e6, time.UTC).UnixNano(), "info",
+			map[string]string{
+				"http.request.method":          "POST",
+				"url.path":                     "/api/v1/locations",
+				"network.protocol.name":        "http",
+				"network.protocol.version":     "2",
+				"http.response.status_code":    "204",
+				"http.request.body.size":       "154",
+				"http.response.body.size":      "0",
+				"http.server.request.duration": "0.226",
+				"envoy.upstream_service_time":  "100",
+				"client.address":               "10.0.35.28",
+				"user_agent.original":          "nsq2http",
+				"http.request.id":              "cc21d9b0-cf5c-432b-8c7e-98aeb7988cd2",
+				"server.address":               "locations",
+				"envoy.upstream_host":          "tcp://10.0.2.1:80",
+			},
+		},
+		{
+			"caddy",
+			`{"level":"info","ts":1714557600.123,"logger":"http.log.access","msg":"handled request","request":{"remote_ip":"172.17.0.1","proto":"HTTP/1.1","method":"GET","host":"example.com","uri":"/index.html","headers":{"User-Agent":["curl/8.4.0"]}},"duration":0.0015,"size":1024,"status":500}`,
+			time.Date(2024, 5, 1, 10, 0, 0, 123e6, time.UTC).UnixNano(), "error",
+			map[string]string{
+				"client.address":               "172.17.0.1",
+				"http.request.method":          "GET",
+				"url.path":                     "/index.html",
+				"server.address":               "example.com",
+				"network.protocol.name":        "http",
+				"network.protocol.version":     "1.1",
+				"http.response.status_code":    "500",
+				"http.response.body.size":      "1024",
+				"http.server.request.duration": "0.0015",
+				"user_agent.original":          "curl/8.4.0",
+				"caddy.logger":                 "http.log.access",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			logMessage := newTestParseMessage(tt.data)
+			if !accessLogFormats[tt.format].parse(tt.data, nil, &logMessage) {
+				t.Fatal("parse() = false; want true")
+			}
+			if logMessage.Timestamp != tt.wantTimestamp {
+				t.Errorf("Timestamp = %d; want %d", logMessage.Timestamp, tt.wantTimestamp)
+			}
+			if logMessage.SeverityText != tt.wantSeverity {
+				t.Errorf("SeverityText = %q; want %q", logMessage.SeverityText, tt.wantSeverity)
+			}
+			if logMessage.Message != tt.data {
+				t.Errorf("Message = %q; want the access log line", logMessage.Message)
+			}
+			for key, want := range tt.want {
+				if got := logMessage.Attributes[key]; got != want {
+					t.Errorf("Attributes[%q] = %q; want %q", key, got, want)
+				}
+			}
+			for key, value := range logMessage.Attributes {
+				if _, expected := tt.want[key]; !expected {
+					t.Errorf("Unexpected attribute %s=%q", key, value)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessLogFormatMismatch(t *testing.T) {
+	for format, p := range accessLogFormats {
+		logMessage := newTestParseMessage("plain text")
+		if p.parse("plain text", nil, &logMessage) {
+			t.Errorf("%s parse() of plain text = true", format)
+		}
+	}
+}
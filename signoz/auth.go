@@ -0,0 +1,65 @@
+package signoz
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+// getoptSecret reads a secret route option. Besides the option and its SIGNOZ_* environment
+// variable, the secret can be read from the file named by the option with a "_file" suffix or
+// by the environment variable with a _FILE suffix, so it does not show up in docker inspect.
+func getoptSecret(route *router.Route, key string) (string, error) {
+	if value := getopt(route, key, ""); value != "" {
+		return value, nil
+	}
+	path := route.Options[key+"_file"]
+	if path == "" {
+		path = os.Getenv(optionEnv(key) + "_FILE")
+	}
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("signoz: cannot read %s from file: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// newHeaders returns the headers sent with every request: the custom headers option plus the
+// ones derived from the ingestion key, bearer token or basic auth credentials.
+func newHeaders(route *router.Route) (map[string]string, error) {
+	headers := parseHeaders(getopt(route, "headers", ""))
+
+	ingestionKey, err := getoptSecret(route, "ingestion_key")
+	if err != nil {
+		return nil, err
+	}
+	if ingestionKey != "" {
+		headers["signoz-ingestion-key"] = ingestionKey
+	}
+
+	bearerToken, err := getoptSecret(route, "bearer_token")
+	if err != nil {
+		return nil, err
+	}
+	username := getopt(route, "basic_auth.username", "")
+	password, err := getoptSecret(route, "basic_auth.password")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bearerToken != "" && username != "":
+		return nil, fmt.Errorf("signoz: bearer_token and basic_auth cannot be used together")
+	case bearerToken != "":
+		headers["Authorization"] = "Bearer " + bearerToken
+	case username != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	}
+	return headers, nil
+}
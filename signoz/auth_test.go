@@ -0,0 +1,94 @@
+package signoz
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestNewHeaders(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	os.WriteFile(secretFile, []byte("s3cret\n"), 0o600)
+
+	tests := []struct {
+		name    string
+		options map[string]string
+		env     map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "Custom headers",
+			options: map[string]string{"headers": "X-Tenant:a, X-Team:b"},
+			want:    map[string]string{"X-Tenant": "a", "X-Team": "b"},
+		},
+		{
+			name:    "Ingestion key",
+			options: map[string]string{"ingestion_key": "key"},
+			want:    map[string]string{"signoz-ingestion-key": "key"},
+		},
+		{
+			name: "Ingestion key from file via env",
+			env:  map[string]string{"SIGNOZ_INGESTION_KEY_FILE": secretFile},
+			want: map[string]string{"signoz-ingestion-key": "s3cret"},
+		},
+		{
+			name:    "Bearer token",
+			options: map[string]string{"bearer_token": "token"},
+			want:    map[string]string{"Authorization": "Bearer token"},
+		},
+		{
+			name:    "Basic auth with password file",
+			options: map[string]string{"basic_auth.username": "user", "basic_auth.password_file": secretFile},
+			want:    map[string]string{"Authorization": "Basic dXNlcjpzM2NyZXQ="},
+		},
+		{
+			name:    "Missing secret file",
+			options: map[string]string{"bearer_token_file": "/does/not/exist"},
+			wantErr: true,
+		},
+		{
+			name:    "Bearer token and basic auth",
+			options: map[string]string{"bearer_token": "token", "basic_auth.username": "user"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			got, err := newHeaders(&router.Route{Options: tt.options})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("newHeaders() = %v; want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("newHeaders()[%s] = %q; want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestExportSendsHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("signoz-ingestion-key") != "key" {
+			t.Errorf("Expected signoz-ingestion-key: key, got: %s", r.Header.Get("signoz-ingestion-key"))
+		}
+	}))
+	defer server.Close()
+
+	exporter := &jsonExporter{&httpSender{endpoint: server.URL, headers: map[string]string{"signoz-ingestion-key": "key"}}}
+	if err := exporter.export([]LogMessage{{Message: "hello"}}); err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+}
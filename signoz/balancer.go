@@ -0,0 +1,196 @@
+package signoz
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+const (
+	strategyRoundRobin       = "round_robin"
+	strategyLeastOutstanding = "least_outstanding"
+	strategyFailover         = "failover"
+
+	probeTimeout = 2 * time.Second
+)
+
+// probeEndpoint checks whether an ejected endpoint accepts connections again. It is replaced in tests.
+var probeEndpoint = func(address string) error {
+	conn, err := net.DialTimeout("tcp", address, probeTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// balancedEndpoint is one collector behind a balancer.
+type balancedEndpoint struct {
+	exporter     exporter
+	name         string
+	address      string
+	outstanding  int
+	failures     int
+	ejectedUntil time.Time
+	probing      bool
+}
+
+// balancer spreads batches over several collectors. It implements exporter itself: a batch that
+// fails with a retryable error on one endpoint is tried on the next one right away. An endpoint
+// that fails maxFailures times in a row is ejected for ejectDuration, and once that has passed
+// it is only reinstated after a successful connection probe, which runs in the background.
+type balancer struct {
+	strategy      string
+	maxFailures   int
+	ejectDuration time.Duration
+
+	mu        sync.Mutex
+	endpoints []*balancedEndpoint
+	next      int
+}
+
+func newBalancer(route *router.Route, endpoints []string, exporters []exporter) (*balancer, error) {
+	b := &balancer{strategy: getopt(route, "loadbalance", strategyRoundRobin)}
+	if b.strategy != strategyRoundRobin && b.strategy != strategyLeastOutstanding && b.strategy != strategyFailover {
+		return nil, fmt.Errorf("signoz: invalid loadbalance %q", b.strategy)
+	}
+	var err error
+	if b.maxFailures, err = getoptInt(route, "loadbalance.max_failures", 3); err != nil {
+		return nil, err
+	}
+	if b.ejectDuration, err = getoptDuration(route, "loadbalance.eject_duration", 30*time.Second); err != nil {
+		return nil, err
+	}
+
+	for i, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		address := u.Host
+		if u.Port() == "" {
+			port := "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+			address = net.JoinHostPort(u.Hostname(), port)
+		}
+		b.endpoints = append(b.endpoints, &balancedEndpoint{exporter: exporters[i], name: endpoint, address: address})
+	}
+	return b, nil
+}
+
+func (b *balancer) export(logs []LogMessage) error {
+	var lastErr error
+	tried := make(map[*balancedEndpoint]bool)
+	for {
+		endpoint := b.pick(tried)
+		if endpoint == nil {
+			if lastErr == nil {
+				lastErr = &exportError{err: errors.New("all endpoints are ejected"), retryable: true}
+			}
+			return lastErr
+		}
+		tried[endpoint] = true
+
+		err := endpoint.exporter.export(logs)
+		b.report(endpoint, err)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+		lastErr = err
+	}
+}
+
+// pick returns the endpoint for the next attempt according to the strategy, skipping the ones
+// already tried for this batch and the ejected ones.
+func (b *balancer) pick(tried map[*balancedEndpoint]bool) *balancedEndpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var candidates []*balancedEndpoint
+	for i := range b.endpoints {
+		endpoint := b.endpoints[i]
+		if b.strategy == strategyRoundRobin {
+			endpoint = b.endpoints[(b.next+i)%len(b.endpoints)]
+		}
+		if !tried[endpoint] && b.available(endpoint) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	picked := candidates[0]
+	if b.strategy == strategyLeastOutstanding {
+		for _, endpoint := range candidates[1:] {
+			if endpoint.outstanding < picked.outstanding {
+				picked = endpoint
+			}
+		}
+	}
+	if b.strategy == strategyRoundRobin {
+		for i, endpoint := range b.endpoints {
+			if endpoint == picked {
+				b.next = i + 1
+			}
+		}
+	}
+	picked.outstanding++
+	return picked
+}
+
+// available reports whether endpoint may receive a batch. An ejected endpoint stays unavailable
+// until a probe, started once its ejection has expired, reinstates it. b.mu must be held.
+func (b *balancer) available(endpoint *balancedEndpoint) bool {
+	if endpoint.ejectedUntil.IsZero() {
+		return true
+	}
+	if !endpoint.probing && !time.Now().Before(endpoint.ejectedUntil) {
+		endpoint.probing = true
+		go b.probe(endpoint)
+	}
+	return false
+}
+
+// probe reinstates endpoint if it accepts connections again and ejects it for another
+// ejectDuration otherwise. The probe may take up to probeTimeout, so it runs without b.mu held.
+func (b *balancer) probe(endpoint *balancedEndpoint) {
+	err := probeEndpoint(endpoint.address)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	endpoint.probing = false
+	if err != nil {
+		endpoint.ejectedUntil = time.Now().Add(b.ejectDuration)
+		return
+	}
+	log.Printf("Endpoint %s is reachable again, reinstating it", endpoint.name)
+	endpoint.ejectedUntil = time.Time{}
+	endpoint.failures = 0
+}
+
+// report records the outcome of an export. Permanent errors say nothing about the health of
+// the endpoint and don't count as failures.
+func (b *balancer) report(endpoint *balancedEndpoint, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	endpoint.outstanding--
+	if err == nil || !isRetryable(err) {
+		endpoint.failures = 0
+		return
+	}
+	endpoint.failures++
+	if b.maxFailures > 0 && endpoint.failures >= b.maxFailures && endpoint.ejectedUntil.IsZero() {
+		endpoint.ejectedUntil = time.Now().Add(b.ejectDuration)
+		metrics.Add("endpoints_ejected", 1)
+		log.Printf("Ejecting endpoint %s for %s after %d consecutive failures", endpoint.name, b.ejectDuration, endpoint.failures)
+	}
+}
@@ -0,0 +1,135 @@
+package signoz
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+// mockExporter records its calls and fails while err is set.
+type mockExporter struct {
+	calls int
+	err   error
+}
+
+func (e *mockExporter) export(logs []LogMessage) error {
+	e.calls++
+	return e.err
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	first, second := &mockExporter{}, &mockExporter{}
+	b, err := newBalancer(&router.Route{}, []string{"http://collector1:8082", "http://collector2:8082"}, []exporter{first, second})
+	if err != nil {
+		t.Fatalf("newBalancer() error = %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := b.export(nil); err != nil {
+			t.Fatalf("export() error = %v", err)
+		}
+	}
+	if first.calls != 2 || second.calls != 2 {
+		t.Errorf("calls = %d, %d; want 2, 2", first.calls, second.calls)
+	}
+}
+
+func TestBalancerFailover(t *testing.T) {
+	primary := &mockExporter{err: &exportError{err: errors.New("503"), retryable: true}}
+	secondary := &mockExporter{}
+	b, err := newBalancer(&router.Route{Options: map[string]string{"loadbalance": "failover", "loadbalance.max_failures": "2"}}, []string{"http://collector1:8082", "http://collector2:8082"}, []exporter{primary, secondary})
+	if err != nil {
+		t.Fatalf("newBalancer() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.export(nil); err != nil {
+			t.Fatalf("export() error = %v", err)
+		}
+	}
+	// The primary is ejected after two failures and not tried for the third batch.
+	if primary.calls != 2 || secondary.calls != 3 {
+		t.Errorf("calls = %d, %d; want 2, 3", primary.calls, secondary.calls)
+	}
+}
+
+func TestBalancerPermanentErrorIsNotFailedOver(t *testing.T) {
+	primary := &mockExporter{err: &exportError{err: errors.New("400")}}
+	secondary := &mockExporter{}
+	b, err := newBalancer(&router.Route{Options: map[string]string{"loadbalance": "failover"}}, []string{"http://collector1:8082", "http://collector2:8082"}, []exporter{primary, secondary})
+	if err != nil {
+		t.Fatalf("newBalancer() error = %v", err)
+	}
+
+	if err := b.export(nil); err == nil {
+		t.Fatal("export() error = nil; want permanent error")
+	}
+	if secondary.calls != 0 {
+		t.Errorf("secondary calls = %d; want 0", secondary.calls)
+	}
+}
+
+func TestBalancerLeastOutstanding(t *testing.T) {
+	first, second := &mockExporter{}, &mockExporter{}
+	b, err := newBalancer(&router.Route{Options: map[string]string{"loadbalance": "least_outstanding"}}, []string{"http://collector1:8082", "http://collector2:8082"}, []exporter{first, second})
+	if err != nil {
+		t.Fatalf("newBalancer() error = %v", err)
+	}
+
+	busy := b.pick(map[*balancedEndpoint]bool{})
+	picked := b.pick(map[*balancedEndpoint]bool{})
+	if picked == busy {
+		t.Error("pick() returned the endpoint with an outstanding request")
+	}
+}
+
+func TestBalancerProbesBeforeReinstating(t *testing.T) {
+	probes := make(chan error)
+	original := probeEndpoint
+	probeEndpoint = func(string) error { return <-probes }
+	defer func() { probeEndpoint = original }()
+
+	failing := &mockExporter{err: &exportError{err: errors.New("503"), retryable: true}}
+	b, err := newBalancer(&router.Route{Options: map[string]string{"loadbalance.max_failures": "1", "loadbalance.eject_duration": "1ms"}}, []string{"http://collector1:8082"}, []exporter{failing})
+	if err != nil {
+		t.Fatalf("newBalancer() error = %v", err)
+	}
+	probed := func(err error) {
+		probes <- err
+		for {
+			b.mu.Lock()
+			probing := b.endpoints[0].probing
+			b.mu.Unlock()
+			if !probing {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if err := b.export(nil); !isRetryable(err) {
+		t.Fatalf("export() error = %v; want retryable", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// The probe is still pending, and exports don't wait for it.
+	for i := 0; i < 2; i++ {
+		if err := b.export(nil); !isRetryable(err) || failing.calls != 1 {
+			t.Fatalf("export() = %v after %d calls; want endpoint to stay ejected while it is probed", err, failing.calls)
+		}
+	}
+
+	probed(errors.New("connection refused"))
+	time.Sleep(5 * time.Millisecond)
+	if err := b.export(nil); !isRetryable(err) || failing.calls != 1 {
+		t.Fatalf("export() = %v after %d calls; want endpoint to stay ejected while the probe fails", err, failing.calls)
+	}
+
+	probed(nil)
+	failing.err = nil
+	if err := b.export(nil); err != nil || failing.calls != 2 {
+		t.Errorf("export() = %v after %d calls; want endpoint reinstated after a successful probe", err, failing.calls)
+	}
+}
@@ -0,0 +1,95 @@
+package signoz
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+	"google.golang.org/protobuf/proto"
+)
+
+// batchConfig decides when buffered records are flushed and how large a single request may be.
+// A flush happens as soon as maxRecords records or maxBytes bytes are buffered, and at the
+// latest timeout after the previous flush. encodedSize returns the size of the request body
+// for a batch in the format of the route, before compression.
+type batchConfig struct {
+	maxRecords  int
+	maxBytes    int64
+	timeout     time.Duration
+	encodedSize func([]LogMessage) int64
+}
+
+func newBatchConfig(route *router.Route) (batchConfig, error) {
+	var c batchConfig
+	protocol, _, err := transportsFromRoute(route)
+	if err != nil {
+		return c, err
+	}
+	c.encodedSize = jsonSize
+	if protocol != protocolJSON {
+		c.encodedSize = otlpSize
+	}
+	if c.maxRecords, err = getoptInt(route, "batch.max_records", 1000); err != nil {
+		return c, err
+	}
+	if c.maxBytes, err = getoptSize(route, "batch.max_bytes", 1<<20); err != nil {
+		return c, err
+	}
+	if c.timeout, err = getoptDuration(route, "batch.timeout", 5*time.Second); err != nil {
+		return c, err
+	}
+	if c.timeout <= 0 {
+		c.timeout = 5 * time.Second
+	}
+	return c, nil
+}
+
+// reached reports whether a buffer holding records records of the given total size should be
+// flushed right away.
+func (c batchConfig) reached(records int, bytes int64) bool {
+	return (c.maxRecords > 0 && records >= c.maxRecords) || (c.maxBytes > 0 && bytes >= c.maxBytes)
+}
+
+// split cuts records into batches that stay within maxRecords and maxBytes. A single record
+// larger than maxBytes is sent on its own. Records are grouped by their estimated size first,
+// and a batch that still exceeds maxBytes once encoded, e.g. because of JSON escaping, is
+// halved until it fits.
+func (c batchConfig) split(records []LogMessage) [][]LogMessage {
+	var batches [][]LogMessage
+	start, bytes := 0, int64(0)
+	for i, record := range records {
+		size := record.size()
+		if i > start && ((c.maxRecords > 0 && i-start >= c.maxRecords) || (c.maxBytes > 0 && bytes+size > c.maxBytes)) {
+			batches = c.fit(batches, records[start:i])
+			start, bytes = i, 0
+		}
+		bytes += size
+	}
+	if start < len(records) {
+		batches = c.fit(batches, records[start:])
+	}
+	return batches
+}
+
+// fit appends batch to batches, halving it while its encoded size exceeds maxBytes.
+func (c batchConfig) fit(batches [][]LogMessage, batch []LogMessage) [][]LogMessage {
+	if c.maxBytes <= 0 || c.encodedSize == nil || len(batch) == 1 || c.encodedSize(batch) <= c.maxBytes {
+		return append(batches, batch)
+	}
+	half := len(batch) / 2
+	return c.fit(c.fit(batches, batch[:half]), batch[half:])
+}
+
+// jsonSize returns the size of the body jsonExporter posts for logs.
+func jsonSize(logs []LogMessage) int64 {
+	data, err := json.Marshal(logs)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
+
+// otlpSize returns the size of the OTLP request the protobuf exporters send for logs.
+func otlpSize(logs []LogMessage) int64 {
+	return int64(proto.Size(toOTLPRequest(logs)))
+}
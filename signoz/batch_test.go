@@ -0,0 +1,112 @@
+package signoz
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestBatchSplit(t *testing.T) {
+	records := make([]LogMessage, 5)
+	for i := range records {
+		records[i] = LogMessage{Message: string(make([]byte, 100))}
+	}
+	recordSize := records[0].size()
+
+	tests := []struct {
+		name   string
+		config batchConfig
+		want   []int
+	}{
+		{"No limits", batchConfig{}, []int{5}},
+		{"By records", batchConfig{maxRecords: 2}, []int{2, 2, 1}},
+		{"By bytes", batchConfig{maxBytes: 3 * recordSize}, []int{3, 2}},
+		{"Oversized record on its own", batchConfig{maxBytes: recordSize / 2}, []int{1, 1, 1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches := tt.config.split(records)
+			if len(batches) != len(tt.want) {
+				t.Fatalf("split() returned %d batches; want %d", len(batches), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if len(batches[i]) != want {
+					t.Errorf("batch %d has %d records; want %d", i, len(batches[i]), want)
+				}
+			}
+		})
+	}
+
+	if batches := (batchConfig{}).split(nil); len(batches) != 0 {
+		t.Errorf("split(nil) = %v; want no batches", batches)
+	}
+}
+
+func TestBatchSplitEncodedSize(t *testing.T) {
+	// json.Marshal escapes <, > and & as \u003c and so on, which the estimate does not see.
+	records := make([]LogMessage, 100)
+	for i := range records {
+		records[i] = LogMessage{Message: strings.Repeat("<a href=\"x?a=1&b=2\">", 50)}
+	}
+
+	for _, route := range []*router.Route{{Adapter: "signoz"}, {Adapter: "signoz+otlp"}} {
+		route.Options = map[string]string{"batch.max_bytes": "20KB"}
+		c, err := newBatchConfig(route)
+		if err != nil {
+			t.Fatalf("newBatchConfig() error = %v", err)
+		}
+		total := 0
+		for _, batch := range c.split(records) {
+			if size := c.encodedSize(batch); size > c.maxBytes {
+				t.Errorf("%s: batch of %d records encodes to %d bytes; want at most %d", route.Adapter, len(batch), size, c.maxBytes)
+			}
+			total += len(batch)
+		}
+		if total != len(records) {
+			t.Errorf("%s: split() returned %d records; want %d", route.Adapter, total, len(records))
+		}
+	}
+}
+
+func TestStreamFlushesFullBatch(t *testing.T) {
+	received := make(chan int, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		received <- len(logs)
+	}))
+	defer server.Close()
+
+	route := &router.Route{Options: map[string]string{"batch.max_records": "2", "batch.timeout": "1h"}}
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	adapter, err := NewSignozAdapter(route)
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+
+	logStream := make(chan *router.Message)
+	go adapter.Stream(logStream)
+	for i := 0; i < 2; i++ {
+		logStream <- &router.Message{
+			Container: &docker.Container{Config: &docker.Config{Image: "serviceImage"}},
+			Data:      "plain message",
+			Time:      time.Now(),
+		}
+	}
+
+	select {
+	case n := <-received:
+		if n != 2 {
+			t.Errorf("Expected a batch of 2 logs, got %d", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Full batch was not flushed before batch.timeout")
+	}
+}
@@ -0,0 +1,105 @@
+package signoz
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+// errCircuitOpen is returned instead of exporting while the circuit breaker is open.
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker stops exports while the collector is down. It wraps an exporter and opens
+// after failureThreshold consecutive transient failures. While it is open, exports fail right
+// away with errCircuitOpen; once coolDown has passed a single export is let through as a probe,
+// which closes the circuit again when it succeeds and reopens it when it fails.
+type circuitBreaker struct {
+	exporter         exporter
+	failureThreshold int
+	coolDown         time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// newCircuitBreaker wraps exp in the circuit breaker configured for route. It returns exp
+// unchanged when circuit_breaker.failure_threshold is 0.
+func newCircuitBreaker(route *router.Route, exp exporter) (exporter, error) {
+	b := &circuitBreaker{exporter: exp, state: circuitClosed}
+	var err error
+	if b.failureThreshold, err = getoptInt(route, "circuit_breaker.failure_threshold", 5); err != nil {
+		return nil, err
+	}
+	if b.coolDown, err = getoptDuration(route, "circuit_breaker.cool_down", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if b.failureThreshold <= 0 {
+		return exp, nil
+	}
+	return b, nil
+}
+
+func (b *circuitBreaker) export(logs []LogMessage) error {
+	if wait, ok := b.allow(); !ok {
+		return &exportError{err: errCircuitOpen, retryable: true, retryAfter: wait}
+	}
+	err := b.exporter.export(logs)
+	b.report(err)
+	return err
+}
+
+// allow reports whether an export may go ahead. When it may not, it also returns the time
+// left until the next probe.
+func (b *circuitBreaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if wait := b.coolDown - time.Since(b.openedAt); wait > 0 {
+			return wait, false
+		}
+		b.state = circuitHalfOpen
+		return 0, true
+	case circuitHalfOpen:
+		// A probe is in flight.
+		return b.coolDown, false
+	default:
+		return 0, true
+	}
+}
+
+// report records the outcome of an export. Like in the balancer, permanent errors say nothing
+// about the health of the collector.
+func (b *circuitBreaker) report(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || !isRetryable(err) {
+		if b.state != circuitClosed {
+			log.Println("Collector is reachable again, closing circuit breaker")
+		}
+		b.state, b.failures = circuitClosed, 0
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.failureThreshold {
+		if b.state == circuitClosed {
+			metrics.Add("circuit_breaker_opened", 1)
+			log.Printf("Opening circuit breaker for %s after %d consecutive failures: %v", b.coolDown, b.failures, err)
+		}
+		b.state, b.openedAt = circuitOpen, time.Now()
+	}
+}
@@ -0,0 +1,88 @@
+package signoz
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestCircuitBreakerStates(t *testing.T) {
+	collector := &mockExporter{err: &exportError{err: errors.New("503"), retryable: true}}
+	breaker, err := newCircuitBreaker(&router.Route{Options: map[string]string{
+		"circuit_breaker.failure_threshold": "2",
+		"circuit_breaker.cool_down":         "50ms",
+	}}, collector)
+	if err != nil {
+		t.Fatalf("newCircuitBreaker() error = %v", err)
+	}
+	b := breaker.(*circuitBreaker)
+
+	b.export(nil)
+	b.export(nil)
+	if b.state != circuitOpen {
+		t.Fatalf("state = %s after 2 failures; want %s", b.state, circuitOpen)
+	}
+
+	err = b.export(nil)
+	if !errors.Is(err, errCircuitOpen) || !isRetryable(err) || retryAfter(err) <= 0 {
+		t.Errorf("export() while open error = %v; want retryable errCircuitOpen with a wait", err)
+	}
+	if collector.calls != 2 {
+		t.Errorf("collector calls = %d while open; want 2", collector.calls)
+	}
+
+	// The probe after the cool-down fails and reopens the circuit.
+	time.Sleep(60 * time.Millisecond)
+	b.export(nil)
+	if collector.calls != 3 || b.state != circuitOpen {
+		t.Errorf("calls = %d, state = %s after failed probe; want 3, %s", collector.calls, b.state, circuitOpen)
+	}
+
+	// A successful probe closes it.
+	time.Sleep(60 * time.Millisecond)
+	collector.err = nil
+	if err := b.export(nil); err != nil || b.state != circuitClosed {
+		t.Errorf("export() = %v, state = %s after successful probe; want nil, %s", err, b.state, circuitClosed)
+	}
+}
+
+func TestCircuitBreakerIgnoresPermanentErrors(t *testing.T) {
+	collector := &mockExporter{err: &exportError{err: errors.New("400")}}
+	breaker, err := newCircuitBreaker(&router.Route{Options: map[string]string{"circuit_breaker.failure_threshold": "1"}}, collector)
+	if err != nil {
+		t.Fatalf("newCircuitBreaker() error = %v", err)
+	}
+	b := breaker.(*circuitBreaker)
+
+	b.export(nil)
+	b.export(nil)
+	if b.state != circuitClosed || collector.calls != 2 {
+		t.Errorf("state = %s, calls = %d; want %s, 2", b.state, collector.calls, circuitClosed)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	collector := &mockExporter{}
+	exp, err := newCircuitBreaker(&router.Route{Options: map[string]string{"circuit_breaker.failure_threshold": "0"}}, collector)
+	if err != nil || exp != exporter(collector) {
+		t.Errorf("newCircuitBreaker() = %v, %v; want the exporter itself", exp, err)
+	}
+}
+
+func TestRetryStopsWhenCircuitOpens(t *testing.T) {
+	stubSleep(t)
+	collector := &mockExporter{err: &exportError{err: errors.New("503"), retryable: true}}
+	breaker, err := newCircuitBreaker(&router.Route{Options: map[string]string{"circuit_breaker.failure_threshold": "3"}}, collector)
+	if err != nil {
+		t.Fatalf("newCircuitBreaker() error = %v", err)
+	}
+	b := breaker.(*circuitBreaker)
+
+	c := retryConfig{initialInterval: time.Second, maxInterval: time.Minute, multiplier: 2, maxAttempts: 10}
+	err = c.do(nil, func() error { return b.export(nil) })
+	if !errors.Is(err, errCircuitOpen) || collector.calls != 3 {
+		t.Errorf("do() = %v after %d calls; want errCircuitOpen after 3", err, collector.calls)
+	}
+}
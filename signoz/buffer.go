@@ -0,0 +1,141 @@
+package signoz
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+const (
+	bufferPolicyBlock      = "block"
+	bufferPolicyDropNewest = "drop_newest"
+	bufferPolicyDropOldest = "drop_oldest"
+)
+
+// logBuffer holds records between flushes. It is bounded by record count and approximate size
+// in bytes; when it is full, policy decides whether add blocks until the next flush, drops the
+// new record or drops the oldest buffered one.
+type logBuffer struct {
+	maxRecords int
+	maxBytes   int64
+	policy     string
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	records []LogMessage
+	bytes   int64
+	dropped int64
+	// draining is set on shutdown, when the last records are accepted without limits.
+	draining bool
+}
+
+func newLogBuffer(route *router.Route) (*logBuffer, error) {
+	b := &logBuffer{}
+	b.notFull = sync.NewCond(&b.mu)
+	var err error
+	if b.maxRecords, err = getoptInt(route, "buffer.max_records", 10000); err != nil {
+		return nil, err
+	}
+	if b.maxBytes, err = getoptSize(route, "buffer.max_bytes", 16<<20); err != nil {
+		return nil, err
+	}
+	b.policy = getopt(route, "buffer.policy", bufferPolicyBlock)
+	if b.policy != bufferPolicyBlock && b.policy != bufferPolicyDropNewest && b.policy != bufferPolicyDropOldest {
+		return nil, fmt.Errorf("signoz: invalid buffer.policy %q", b.policy)
+	}
+	return b, nil
+}
+
+// size estimates the encoded size of a record from the length of its fields. It bounds the
+// buffer and groups records into batches, whose encoded size split checks afterwards.
+func (m LogMessage) size() int64 {
+	size := 100 + len(m.SeverityText) + len(m.Message) + len(m.TraceID) + len(m.SpanID)
+	for key, value := range m.Attributes {
+		size += len(key) + len(value) + 6
+	}
+	for key, value := range m.Resources {
+		size += len(key) + len(value) + 6
+	}
+	return int64(size)
+}
+
+// full reports whether a record of the given size does not fit. An empty buffer always accepts
+// a record, so an oversized one cannot block forever.
+func (b *logBuffer) full(size int64) bool {
+	if len(b.records) == 0 {
+		return false
+	}
+	return (b.maxRecords > 0 && len(b.records) >= b.maxRecords) ||
+		(b.maxBytes > 0 && b.bytes+size > b.maxBytes)
+}
+
+// add appends a record, applying the buffer policy when the buffer is full. It returns the
+// number of buffered records and their size afterwards.
+func (b *logBuffer) add(m LogMessage) (int, int64) {
+	size := m.size()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for !b.draining && b.full(size) {
+		switch b.policy {
+		case bufferPolicyDropNewest:
+			b.drop()
+			return len(b.records), b.bytes
+		case bufferPolicyDropOldest:
+			b.bytes -= b.records[0].size()
+			b.records[0] = LogMessage{}
+			b.records = b.records[1:]
+			b.drop()
+		default:
+			metrics.Add("buffer_blocked", 1)
+			b.notFull.Wait()
+		}
+	}
+	b.records = append(b.records, m)
+	b.bytes += size
+	return len(b.records), b.bytes
+}
+
+// drain lifts the limits for the records still coming in during shutdown, so add no longer
+// blocks or drops, and wakes up the callers waiting in add.
+func (b *logBuffer) drain() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.draining = true
+	b.notFull.Broadcast()
+}
+
+// requeue puts records taken earlier back in front of the buffer, so they count against its
+// limits again and are taken first.
+func (b *logBuffer) requeue(records []LogMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, m := range records {
+		b.bytes += m.size()
+	}
+	b.records = append(records, b.records...)
+}
+
+// len returns the number of buffered records.
+func (b *logBuffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.records)
+}
+
+func (b *logBuffer) drop() {
+	b.dropped++
+	metrics.Add("buffer_dropped_records", 1)
+}
+
+// take empties the buffer and returns its records together with the number of records
+// dropped since the previous call.
+func (b *logBuffer) take() ([]LogMessage, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	records, dropped := b.records, b.dropped
+	b.records, b.bytes, b.dropped = nil, 0, 0
+	b.notFull.Broadcast()
+	return records, dropped
+}
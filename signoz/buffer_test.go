@@ -0,0 +1,112 @@
+package signoz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestLogBufferDropPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{bufferPolicyDropNewest, []string{"1", "2"}},
+		{bufferPolicyDropOldest, []string{"3", "4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			b, err := newLogBuffer(&router.Route{Options: map[string]string{"buffer.max_records": "2", "buffer.policy": tt.policy}})
+			if err != nil {
+				t.Fatalf("newLogBuffer() error = %v", err)
+			}
+			for _, msg := range []string{"1", "2", "3", "4"} {
+				b.add(LogMessage{Message: msg})
+			}
+
+			records, dropped := b.take()
+			if dropped != 2 {
+				t.Errorf("take() dropped = %d; want 2", dropped)
+			}
+			if len(records) != len(tt.want) {
+				t.Fatalf("take() returned %d records; want %d", len(records), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if records[i].Message != want {
+					t.Errorf("records[%d] = %s; want %s", i, records[i].Message, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogBufferMaxBytes(t *testing.T) {
+	b, err := newLogBuffer(&router.Route{Options: map[string]string{"buffer.max_bytes": "200", "buffer.policy": bufferPolicyDropNewest}})
+	if err != nil {
+		t.Fatalf("newLogBuffer() error = %v", err)
+	}
+	big := LogMessage{Message: string(make([]byte, 150))}
+	b.add(big)
+	b.add(big)
+
+	records, dropped := b.take()
+	if len(records) != 1 || dropped != 1 {
+		t.Errorf("take() = %d records, %d dropped; want 1, 1", len(records), dropped)
+	}
+}
+
+func TestLogBufferBlocks(t *testing.T) {
+	b, err := newLogBuffer(&router.Route{Options: map[string]string{"buffer.max_records": "1"}})
+	if err != nil {
+		t.Fatalf("newLogBuffer() error = %v", err)
+	}
+	b.add(LogMessage{Message: "1"})
+
+	added := make(chan struct{})
+	go func() {
+		b.add(LogMessage{Message: "2"})
+		close(added)
+	}()
+
+	select {
+	case <-added:
+		t.Fatal("add() returned while the buffer was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if records, _ := b.take(); len(records) != 1 || records[0].Message != "1" {
+		t.Errorf("take() = %v; want [1]", records)
+	}
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("add() still blocked after take()")
+	}
+}
+
+func TestLogBufferRequeue(t *testing.T) {
+	b, err := newLogBuffer(&router.Route{Options: map[string]string{"buffer.max_records": "2", "buffer.policy": bufferPolicyDropNewest}})
+	if err != nil {
+		t.Fatalf("newLogBuffer() error = %v", err)
+	}
+	b.add(LogMessage{Message: "3"})
+	b.requeue([]LogMessage{{Message: "1"}, {Message: "2"}})
+	// The requeued records count against the limit.
+	b.add(LogMessage{Message: "4"})
+
+	records, dropped := b.take()
+	if dropped != 1 {
+		t.Errorf("take() dropped = %d; want 1", dropped)
+	}
+	if len(records) != 3 || records[0].Message != "1" || records[1].Message != "2" || records[2].Message != "3" {
+		t.Errorf("take() = %v; want 1, 2 and 3", records)
+	}
+}
+
+func TestNewLogBufferInvalidPolicy(t *testing.T) {
+	if _, err := newLogBuffer(&router.Route{Options: map[string]string{"buffer.policy": "spill"}}); err == nil {
+		t.Error("newLogBuffer() error = nil; want error")
+	}
+}
@@ -0,0 +1,89 @@
+package signoz
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/gliderlabs/logspout/router"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	compressionNone = "none"
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
+// compression describes how request bodies are compressed. The name doubles as the
+// Content-Encoding header value. A zero level selects the algorithm's default.
+type compression struct {
+	name  string
+	level int
+}
+
+func newCompression(route *router.Route) (compression, error) {
+	c := compression{name: getopt(route, "compression", compressionNone)}
+	var err error
+	if c.level, err = getoptInt(route, "compression.level", 0); err != nil {
+		return c, err
+	}
+
+	switch c.name {
+	case compressionNone:
+	case compressionGzip:
+		if c.level != 0 && (c.level < gzip.HuffmanOnly || c.level > gzip.BestCompression) {
+			return c, fmt.Errorf("signoz: invalid gzip compression.level %d", c.level)
+		}
+	case compressionZstd:
+		if c.level < 0 || c.level > 22 {
+			return c, fmt.Errorf("signoz: invalid zstd compression.level %d", c.level)
+		}
+	default:
+		return c, fmt.Errorf("signoz: unsupported compression %q", c.name)
+	}
+	return c, nil
+}
+
+func (c compression) enabled() bool {
+	return c.name == compressionGzip || c.name == compressionZstd
+}
+
+func (c compression) compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch c.name {
+	case compressionGzip:
+		level := c.level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		w, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case compressionZstd:
+		opts := []zstd.EOption{}
+		if c.level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.level)))
+		}
+		w, err := zstd.NewWriter(&buf, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return data, nil
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,94 @@
+package signoz
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gliderlabs/logspout/router"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNewCompression(t *testing.T) {
+	tests := []struct {
+		options map[string]string
+		wantErr bool
+	}{
+		{map[string]string{}, false},
+		{map[string]string{"compression": "gzip", "compression.level": "9"}, false},
+		{map[string]string{"compression": "zstd", "compression.level": "3"}, false},
+		{map[string]string{"compression": "gzip", "compression.level": "12"}, true},
+		{map[string]string{"compression": "brotli"}, true},
+	}
+
+	for _, tt := range tests {
+		_, err := newCompression(&router.Route{Options: tt.options})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newCompression(%v) error = %v, wantErr %v", tt.options, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCompressedExport(t *testing.T) {
+	for _, name := range []string{compressionGzip, compressionZstd} {
+		t.Run(name, func(t *testing.T) {
+			var received []LogMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Encoding") != name {
+					t.Errorf("Expected Content-Encoding %s, got %s", name, r.Header.Get("Content-Encoding"))
+				}
+				var body io.Reader
+				if name == compressionGzip {
+					body, _ = gzip.NewReader(r.Body)
+				} else {
+					decoder, _ := zstd.NewReader(r.Body)
+					defer decoder.Close()
+					body = decoder
+				}
+				if err := json.NewDecoder(body).Decode(&received); err != nil {
+					t.Errorf("Failed to decode request body: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			exporter := &jsonExporter{&httpSender{endpoint: server.URL, compression: compression{name: name}}}
+			if err := exporter.export([]LogMessage{{Message: "hello"}}); err != nil {
+				t.Fatalf("export() error = %v", err)
+			}
+			if len(received) != 1 || received[0].Message != "hello" {
+				t.Errorf("Expected to receive hello, got %v", received)
+			}
+		})
+	}
+}
+
+func TestCompressionFallbackOn415(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		}
+	}))
+	defer server.Close()
+
+	exporter := &jsonExporter{&httpSender{endpoint: server.URL, compression: compression{name: compressionGzip}}}
+	for i := 0; i < 2; i++ {
+		if err := exporter.export([]LogMessage{{Message: "hello"}}); err != nil {
+			t.Fatalf("export() error = %v", err)
+		}
+	}
+
+	want := []string{"gzip", "", ""}
+	if len(encodings) != len(want) {
+		t.Fatalf("Expected %d requests, got %d: %v", len(want), len(encodings), encodings)
+	}
+	for i := range want {
+		if encodings[i] != want[i] {
+			t.Errorf("request %d Content-Encoding = %q; want %q", i, encodings[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package signoz
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+const deadLetterExt = ".ndjson"
+
+// DeadLetter is one line of a dead-letter file: a batch the collector did not accept, with
+// the reason it was given up on.
+type DeadLetter struct {
+	Time     time.Time    `json:"time"`
+	Status   int          `json:"status,omitempty"`
+	Error    string       `json:"error"`
+	Response string       `json:"response,omitempty"`
+	Logs     []LogMessage `json:"logs"`
+}
+
+// deadLetterSink appends failed batches to NDJSON files in dir. A file is rotated once it
+// reaches maxFileSize and only the newest maxFiles files of a route are kept.
+type deadLetterSink struct {
+	dir         string
+	prefix      string
+	maxFileSize int64
+	maxFiles    int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// newDeadLetterSink returns the sink configured for route, or nil when deadletter.dir is not set.
+func newDeadLetterSink(route *router.Route) (*deadLetterSink, error) {
+	dir := getopt(route, "deadletter.dir", "")
+	if dir == "" {
+		return nil, nil
+	}
+
+	s := &deadLetterSink{dir: dir, prefix: "deadletter-" + routeKey(route) + "-"}
+	var err error
+	if s.maxFileSize, err = getoptSize(route, "deadletter.max_file_size", 64<<20); err != nil {
+		return nil, err
+	}
+	if s.maxFiles, err = getoptInt(route, "deadletter.max_files", 10); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("signoz: cannot create deadletter.dir: %w", err)
+	}
+	return s, nil
+}
+
+// write records a failed batch. Errors are logged, as there is nowhere left to send the batch.
+func (s *deadLetterSink) write(logs []LogMessage, cause error) {
+	if s == nil {
+		log.Printf("Dropping %d log records: %v", len(logs), cause)
+		return
+	}
+
+	entry := DeadLetter{Time: time.Now().UTC(), Error: cause.Error(), Logs: logs}
+	var exportErr *exportError
+	if errors.As(cause, &exportErr) {
+		entry.Status = exportErr.statusCode
+		entry.Response = exportErr.response
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Println("Error encoding dead letter:", err)
+		return
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil || (s.size > 0 && s.size+int64(len(line)) > s.maxFileSize) {
+		if err := s.rotate(); err != nil {
+			log.Println("Error rotating dead-letter file:", err)
+			return
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		log.Println("Error writing dead letter:", err)
+		return
+	}
+	metrics.Add("deadletter_records", int64(len(logs)))
+	log.Printf("Wrote %d log records to dead-letter file %s", len(logs), s.file.Name())
+}
+
+func (s *deadLetterSink) rotate() error {
+	if s.file != nil {
+		s.file.Close()
+	}
+	name := filepath.Join(s.dir, fmt.Sprintf("%s%d%s", s.prefix, time.Now().UnixNano(), deadLetterExt))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		s.file = nil
+		return err
+	}
+	s.file, s.size = file, 0
+
+	if s.maxFiles > 0 {
+		files, _ := filepath.Glob(filepath.Join(s.dir, s.prefix+"*"+deadLetterExt))
+		sort.Strings(files)
+		for len(files) > s.maxFiles {
+			os.Remove(files[0])
+			files = files[1:]
+		}
+	}
+	return nil
+}
+
+// ReplayDeadLetters sends the batches of every dead-letter file in dir to the collectors of
+// route with its batch and retry options, oldest file first. A file is removed once all of its
+// batches were accepted, unless keep is set. It stops at the first batch that cannot be
+// delivered and returns the number of log records replayed so far. Unless keep is set, the
+// file is then rewritten with the records not replayed yet, so a second run does not send
+// the accepted ones again.
+//
+// The newest file of each route may still be written to by a running logspout, so it is
+// skipped unless all is set, which is only safe once logspout is stopped.
+//
+// Only the exporter is built from route, so a replay never opens the disk queue or the
+// dead-letter directory of a running adapter, even if their SIGNOZ_* variables are set.
+func ReplayDeadLetters(dir string, route *router.Route, keep, all bool) (int, error) {
+	exp, err := newExporter(route)
+	if err != nil {
+		return 0, err
+	}
+	retry, err := newRetryConfig(route)
+	if err != nil {
+		return 0, err
+	}
+	batch, err := newBatchConfig(route)
+	if err != nil {
+		return 0, err
+	}
+	maxRecordBytes, err := getoptSize(route, "batch.max_record_bytes", 64<<10)
+	if err != nil {
+		return 0, err
+	}
+	a := &Adapter{route: route, exporter: exp, retry: retry, batch: batch, maxRecordBytes: maxRecordBytes}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+deadLetterExt))
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(files, func(i, j int) bool { return deadLetterCreated(files[i]) < deadLetterCreated(files[j]) })
+	if !all {
+		newest := make(map[string]string)
+		for _, file := range files {
+			newest[deadLetterPrefix(file)] = file
+		}
+		files = slices.DeleteFunc(files, func(file string) bool { return newest[deadLetterPrefix(file)] == file })
+	}
+
+	replayed := 0
+	for _, file := range files {
+		n, err := a.replayFile(file, keep)
+		replayed += n
+		if err != nil {
+			return replayed, fmt.Errorf("%s: %w", file, err)
+		}
+		if !keep {
+			if err := os.Remove(file); err != nil {
+				return replayed, err
+			}
+		}
+	}
+	return replayed, nil
+}
+
+// deadLetterCreated returns the creation timestamp encoded in the name of a dead-letter file.
+func deadLetterCreated(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), deadLetterExt)
+	return fmt.Sprintf("%020s", name[strings.LastIndexByte(name, '-')+1:])
+}
+
+// deadLetterPrefix returns the part of the name of a dead-letter file that identifies its route.
+func deadLetterPrefix(path string) string {
+	name := filepath.Base(path)
+	return name[:strings.LastIndexByte(name, '-')+1]
+}
+
+func (a *Adapter) replayFile(path string, keep bool) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	replayed := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), maxRecordSize)
+	for line := 1; scanner.Scan(); line++ {
+		var entry DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return replayed, fmt.Errorf("line %d: %w", line, err)
+		}
+		// Batches too large for the collector are split and truncated as when they were sent.
+		sent := 0
+		for _, batch := range a.batch.split(entry.Logs) {
+			var err error
+			n, _ := a.deliver(batch, func(_ []LogMessage, cause error) bool {
+				err = cause
+				return false
+			})
+			sent += n
+			replayed += n
+			if err != nil {
+				err = fmt.Errorf("line %d: %w", line, err)
+				if !keep && replayed > 0 {
+					entry.Logs = entry.Logs[sent:]
+					err = errors.Join(err, rewriteDeadLetters(path, entry, scanner))
+				}
+				return replayed, err
+			}
+		}
+	}
+	return replayed, scanner.Err()
+}
+
+// rewriteDeadLetters replaces the dead-letter file at path with entry followed by the lines
+// scanner has not read yet.
+func rewriteDeadLetters(path string, entry DeadLetter, scanner *bufio.Scanner) error {
+	first, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	w := bufio.NewWriter(f)
+	w.Write(first)
+	w.WriteByte('\n')
+	for scanner.Scan() {
+		w.Write(scanner.Bytes())
+		w.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,243 @@
+package signoz
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func readDeadLetters(t *testing.T, dir string) []DeadLetter {
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+deadLetterExt))
+	var entries []DeadLetter
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry DeadLetter
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatalf("Invalid dead-letter line %q: %v", scanner.Text(), err)
+			}
+			entries = append(entries, entry)
+		}
+		f.Close()
+	}
+	return entries
+}
+
+func TestSendWritesDeadLetters(t *testing.T) {
+	stubSleep(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid log record", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	logAdapter, err := NewSignozAdapter(&router.Route{Options: map[string]string{"deadletter.dir": dir}})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+	adapter := logAdapter.(*Adapter)
+
+	logs := []LogMessage{{Message: "first"}, {Message: "second"}}
+	if err := adapter.send(logs, false); err == nil {
+		t.Fatal("send() error = nil; want the 400 error")
+	}
+
+	entries := readDeadLetters(t, dir)
+	if len(entries) != 1 {
+		t.Fatalf("Got %d dead letters; want 1", len(entries))
+	}
+	if entries[0].Status != http.StatusBadRequest || entries[0].Response != "invalid log record\n" || len(entries[0].Logs) != 2 {
+		t.Errorf("Unexpected dead letter %+v", entries[0])
+	}
+}
+
+func TestDeadLetterSinkRotates(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := newDeadLetterSink(&router.Route{Options: map[string]string{
+		"deadletter.dir":           dir,
+		"deadletter.max_file_size": "200",
+		"deadletter.max_files":     "2",
+	}})
+	if err != nil {
+		t.Fatalf("newDeadLetterSink() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		sink.write([]LogMessage{{Message: "a message that fills most of a file"}}, &exportError{err: os.ErrInvalid})
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+deadLetterExt))
+	if len(files) != 2 {
+		t.Errorf("Got %d dead-letter files; want 2", len(files))
+	}
+}
+
+func TestDeadLetterSinkDisabled(t *testing.T) {
+	sink, err := newDeadLetterSink(&router.Route{})
+	if err != nil || sink != nil {
+		t.Fatalf("newDeadLetterSink() = %v, %v; want nil, nil", sink, err)
+	}
+	sink.write([]LogMessage{{Message: "dropped"}}, os.ErrInvalid)
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	stubSleep(t)
+	var accept atomic.Bool
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !accept.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		received.Add(int64(len(logs)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	route := &router.Route{Options: map[string]string{"deadletter.dir": dir}}
+	logAdapter, err := NewSignozAdapter(route)
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+	logAdapter.(*Adapter).send([]LogMessage{{Message: "1"}, {Message: "2"}, {Message: "3"}}, true)
+
+	accept.Store(true)
+	queueDir := t.TempDir()
+	t.Setenv("SIGNOZ_QUEUE_DIR", queueDir)
+	replayed, err := ReplayDeadLetters(dir, &router.Route{}, false, true)
+	if err != nil {
+		t.Fatalf("ReplayDeadLetters() error = %v", err)
+	}
+	if replayed != 3 || received.Load() != 3 {
+		t.Errorf("ReplayDeadLetters() replayed %d, collector received %d; want 3", replayed, received.Load())
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*"+deadLetterExt)); len(files) != 0 {
+		t.Errorf("Expected replayed files to be removed, found %v", files)
+	}
+	if entries, _ := os.ReadDir(queueDir); len(entries) != 0 {
+		t.Errorf("Expected the replay not to open the disk queue, found %v", entries)
+	}
+}
+
+func TestReplayDeadLettersSplitsTooLargeBatches(t *testing.T) {
+	stubSleep(t)
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		if len(logs) > 1 || len(logs[0].Message) > 100 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		received.Add(int64(len(logs)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	sink, err := newDeadLetterSink(&router.Route{Options: map[string]string{"deadletter.dir": dir}})
+	if err != nil {
+		t.Fatalf("newDeadLetterSink() error = %v", err)
+	}
+	tooLarge := &exportError{err: os.ErrInvalid, statusCode: http.StatusRequestEntityTooLarge}
+	sink.write([]LogMessage{{Message: "1"}, {Message: string(make([]byte, 200))}, {Message: "3"}}, tooLarge)
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	replayed, err := ReplayDeadLetters(dir, &router.Route{Options: map[string]string{"batch.max_record_bytes": "50"}}, false, true)
+	if err != nil {
+		t.Fatalf("ReplayDeadLetters() error = %v", err)
+	}
+	if replayed != 3 || received.Load() != 3 {
+		t.Errorf("ReplayDeadLetters() replayed %d, collector received %d; want 3", replayed, received.Load())
+	}
+}
+
+func TestReplayDeadLettersKeepsOnlyRemainingRecords(t *testing.T) {
+	stubSleep(t)
+	var accepted atomic.Int64
+	var limit atomic.Int64
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accepted.Load() >= limit.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		for _, m := range logs {
+			messages = append(messages, m.Message)
+		}
+		accepted.Add(int64(len(logs)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	sink, err := newDeadLetterSink(&router.Route{Options: map[string]string{"deadletter.dir": dir}})
+	if err != nil {
+		t.Fatalf("newDeadLetterSink() error = %v", err)
+	}
+	sink.write([]LogMessage{{Message: "a"}, {Message: "b"}}, os.ErrInvalid)
+	sink.write([]LogMessage{{Message: "c"}}, os.ErrInvalid)
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	route := &router.Route{Options: map[string]string{"batch.max_records": "1", "retry.max_attempts": "1"}}
+	limit.Store(1)
+	if replayed, err := ReplayDeadLetters(dir, route, false, true); err == nil || replayed != 1 {
+		t.Fatalf("ReplayDeadLetters() = %d, %v; want 1 and the 400 error", replayed, err)
+	}
+	entries := readDeadLetters(t, dir)
+	if len(entries) != 2 || len(entries[0].Logs) != 1 || entries[0].Logs[0].Message != "b" || entries[0].Error == "" {
+		t.Fatalf("dead letters after the failed replay = %+v; want b and c", entries)
+	}
+
+	limit.Store(10)
+	if replayed, err := ReplayDeadLetters(dir, route, false, true); err != nil || replayed != 2 {
+		t.Fatalf("ReplayDeadLetters() = %d, %v; want 2", replayed, err)
+	}
+	if strings.Join(messages, "") != "abc" {
+		t.Errorf("collector received %v; want a, b and c once", messages)
+	}
+}
+
+func TestReplayDeadLettersSkipsNewestFile(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		received.Add(int64(len(logs)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	sink, err := newDeadLetterSink(&router.Route{Options: map[string]string{"deadletter.dir": dir, "deadletter.max_file_size": "1"}})
+	if err != nil {
+		t.Fatalf("newDeadLetterSink() error = %v", err)
+	}
+	// Every batch goes to a file of its own, and the sink keeps the last one open.
+	for _, message := range []string{"old", "older", "open"} {
+		sink.write([]LogMessage{{Message: message}}, os.ErrInvalid)
+	}
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	if replayed, err := ReplayDeadLetters(dir, &router.Route{}, false, false); err != nil || replayed != 2 {
+		t.Fatalf("ReplayDeadLetters() = %d, %v; want 2", replayed, err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+deadLetterExt))
+	if len(files) != 1 || files[0] != sink.file.Name() {
+		t.Errorf("dead-letter files after replay = %v; want only %s", files, sink.file.Name())
+	}
+}
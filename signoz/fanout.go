@@ -0,0 +1,119 @@
+package signoz
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+// destinationBacklog is the number of messages a destination may fall behind before messages
+// for it are dropped.
+const destinationBacklog = 1024
+
+// fanout sends the messages of one route to several named destinations. Every destination is
+// a complete Adapter with its own buffer, queue and filters and is fed through its own channel,
+// so a slow destination does not hold up the others.
+type fanout struct {
+	names    []string
+	adapters []*Adapter
+}
+
+func newFanout(route *router.Route) (*fanout, error) {
+	f := &fanout{}
+	for _, name := range splitList(route.Options["destinations"]) {
+		if strings.ContainsAny(name, "./") {
+			return nil, fmt.Errorf("signoz: invalid destination name %q", name)
+		}
+		a, err := newAdapter(destinationRoute(route, name))
+		if err != nil {
+			return nil, fmt.Errorf("signoz: destination %s: %w", name, err)
+		}
+		f.names = append(f.names, name)
+		f.adapters = append(f.adapters, a)
+	}
+	return f, nil
+}
+
+// destinationRoute returns the route of a named destination: the options of route overlaid
+// with the ones prefixed with the destination name, e.g. security.ingestion_key. The address
+// and transport options replace the route address and adapter transports; the endpoints of
+// the route are not inherited then. The queue and dead-letter directories of the route get a
+// subdirectory per destination.
+func destinationRoute(route *router.Route, name string) *router.Route {
+	prefix := name + "."
+	d := &router.Route{
+		ID:      route.ID,
+		Adapter: route.Adapter,
+		Address: route.Address,
+		Options: make(map[string]string),
+	}
+	for key, value := range route.Options {
+		if key != "destinations" {
+			d.Options[key] = value
+		}
+	}
+	for _, key := range []string{"queue.dir", "deadletter.dir"} {
+		if dir := getopt(route, key, ""); dir != "" {
+			d.Options[key] = filepath.Join(dir, name)
+		}
+	}
+
+	for key, value := range route.Options {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		switch key = strings.TrimPrefix(key, prefix); key {
+		case "address":
+			d.Address = value
+			delete(d.Options, "endpoints")
+		case "transport":
+			d.Adapter = strings.Split(route.Adapter, "+")[0] + "+" + value
+		default:
+			d.Options[key] = value
+		}
+	}
+	return d
+}
+
+func (f *fanout) Stream(logStream chan *router.Message) {
+	var wg sync.WaitGroup
+	streams := make([]chan *router.Message, len(f.adapters))
+	for i, a := range f.adapters {
+		streams[i] = make(chan *router.Message, destinationBacklog)
+		wg.Add(1)
+		go func(a *Adapter, stream chan *router.Message) {
+			defer wg.Done()
+			a.Stream(stream)
+		}(a, streams[i])
+	}
+
+	// dropped counts the messages each destination missed since it fell behind, so that is
+	// logged once when it starts and once when it catches up.
+	dropped := make([]int, len(streams))
+	for message := range logStream {
+		for i, stream := range streams {
+			select {
+			case stream <- message:
+				if dropped[i] > 0 {
+					log.Printf("Destination %s caught up, %d messages were dropped", f.names[i], dropped[i])
+					dropped[i] = 0
+				}
+			default:
+				if dropped[i] == 0 {
+					log.Printf("Destination %s is falling behind, dropping messages", f.names[i])
+				}
+				dropped[i]++
+				metrics.Add("destination_dropped_records", 1)
+			}
+		}
+	}
+
+	for _, stream := range streams {
+		close(stream)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,122 @@
+package signoz
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestDestinationRoute(t *testing.T) {
+	route := &router.Route{
+		Adapter: "signoz",
+		Address: "regional:8082",
+		Options: map[string]string{
+			"destinations":           "regional|security",
+			"endpoints":              "regional-2:8082",
+			"ingestion_key":          "regional-key",
+			"queue.dir":              "/var/lib/signoz",
+			"security.address":       "central:4318",
+			"security.transport":     "otlp+https",
+			"security.ingestion_key": "central-key",
+			"security.min_severity":  "ERROR",
+		},
+	}
+
+	regional := destinationRoute(route, "regional")
+	if regional.Address != "regional:8082" || regional.Options["endpoints"] != "regional-2:8082" || regional.Options["ingestion_key"] != "regional-key" {
+		t.Errorf("regional route = %+v; want the route unchanged", regional)
+	}
+	if regional.Options["queue.dir"] != filepath.Join("/var/lib/signoz", "regional") {
+		t.Errorf("regional queue.dir = %q", regional.Options["queue.dir"])
+	}
+
+	security := destinationRoute(route, "security")
+	if security.Adapter != "signoz+otlp+https" || security.Address != "central:4318" {
+		t.Errorf("security route = %s://%s; want signoz+otlp+https://central:4318", security.Adapter, security.Address)
+	}
+	if _, exists := security.Options["endpoints"]; exists {
+		t.Error("Expected security route not to inherit endpoints")
+	}
+	if security.Options["ingestion_key"] != "central-key" || security.Options["min_severity"] != "ERROR" {
+		t.Errorf("security options = %v", security.Options)
+	}
+	if security.Options["queue.dir"] != filepath.Join("/var/lib/signoz", "security") {
+		t.Errorf("security queue.dir = %q", security.Options["queue.dir"])
+	}
+}
+
+func TestFanoutStream(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string][]string)
+	newCollector := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var logs []LogMessage
+			json.NewDecoder(r.Body).Decode(&logs)
+			mu.Lock()
+			defer mu.Unlock()
+			for _, logMessage := range logs {
+				received[name] = append(received[name], logMessage.SeverityText)
+			}
+		}))
+	}
+	regional, security := newCollector("regional"), newCollector("security")
+	defer regional.Close()
+	defer security.Close()
+	regionalURL, _ := url.Parse(regional.URL)
+	securityURL, _ := url.Parse(security.URL)
+
+	adapter, err := NewSignozAdapter(&router.Route{
+		Adapter: "signoz",
+		Address: regionalURL.Host,
+		Options: map[string]string{
+			"destinations":          "regional,security",
+			"security.address":      securityURL.Host,
+			"security.min_severity": "error",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+
+	logStream := make(chan *router.Message, 3)
+	logStream <- newTestMessage("INFO starting")
+	logStream <- newTestMessage("ERROR failed")
+	logStream <- newTestMessage("FATAL crashed")
+	close(logStream)
+	adapter.Stream(logStream)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received["regional"]) != 3 {
+		t.Errorf("regional received %v; want all 3 logs", received["regional"])
+	}
+	if len(received["security"]) != 2 {
+		t.Errorf("security received %v; want the 2 logs of ERROR and above", received["security"])
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"warn", 13, false},
+		{"ERROR", 17, false},
+		{"9", 9, false},
+		{"loud", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSeverity(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseSeverity(%q) = %d, %v; want %d, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
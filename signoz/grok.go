@@ -0,0 +1,150 @@
+package signoz
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// grokPatterns is a library of reusable patterns, referenced as %{NAME} or %{NAME:field} in
+// regex parser patterns. The names follow the Logstash Grok patterns of the same name.
+var grokPatterns = map[string]string{
+	"USERNAME":     `[a-zA-Z0-9._-]+`,
+	"USER":         `%{USERNAME}`,
+	"INT":          `(?:[+-]?[0-9]+)`,
+	"BASE10NUM":    `(?:[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+))`,
+	"NUMBER":       `%{BASE10NUM}`,
+	"POSINT":       `\b(?:[1-9][0-9]*)\b`,
+	"NONNEGINT":    `\b(?:[0-9]+)\b`,
+	"WORD":         `\b\w+\b`,
+	"NOTSPACE":     `\S+`,
+	"SPACE":        `\s*`,
+	"DATA":         `.*?`,
+	"GREEDYDATA":   `.*`,
+	"QUOTEDSTRING": `"(?:[^"\\]|\\.)*"`,
+	"QS":           `%{QUOTEDSTRING}`,
+	"UUID":         `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+
+	"IPV4":     `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`,
+	"IPV6":     `(?:[A-Fa-f0-9]{0,4}:){2,7}[A-Fa-f0-9]{0,4}(?:%[0-9A-Za-z]+)?`,
+	"IP":       `(?:%{IPV6}|%{IPV4})`,
+	"HOSTNAME": `\b(?:[0-9A-Za-z][0-9A-Za-z-]{0,62})(?:\.(?:[0-9A-Za-z][0-9A-Za-z-]{0,62}))*\.?`,
+	"IPORHOST": `(?:%{IP}|%{HOSTNAME})`,
+	"HOSTPORT": `%{IPORHOST}:%{POSINT}`,
+
+	"URIPROTO":     `[A-Za-z][A-Za-z0-9+.\-]*`,
+	"URIPATH":      `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":     `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM": `%{URIPATH}(?:%{URIPARAM})?`,
+	"URI":          `%{URIPROTO}://\S+`,
+
+	"MONTH":             `\b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]un(?:e)?|[Jj]ul(?:y)?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b`,
+	"MONTHNUM":          `(?:0?[1-9]|1[0-2])`,
+	"MONTHDAY":          `(?:0[1-9]|[12][0-9]|3[01]|[1-9])`,
+	"DAY":               `(?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)`,
+	"YEAR":              `(?:\d\d){1,2}`,
+	"HOUR":              `(?:2[0123]|[01]?[0-9])`,
+	"MINUTE":            `(?:[0-5][0-9])`,
+	"SECOND":            `(?:(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?)`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"ISO8601_TIMEZONE":  `(?:Z|[+-]%{HOUR}(?::?%{MINUTE}))`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+
+	"LOGLEVEL": `(?:[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo|INFO|[Ww]arn(?:ing)?|WARN(?:ING)?|[Ee]rr(?:or)?|ERR(?:OR)?|[Cc]rit(?:ical)?|CRIT(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|[Ee]merg(?:ency)?|EMERG(?:ENCY)?)`,
+}
+
+// grokReference matches %{NAME} and %{NAME:field}. Fields may contain dots, e.g. %{IP:client.address}.
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([\w.@-]+))?\}`)
+
+// maxGrokDepth bounds the expansion of patterns referencing each other.
+const maxGrokDepth = 16
+
+// regexParser extracts the fields of a log line with a regular expression. Named groups, either
+// (?P<name>...) or Grok references with a field, are the extracted fields.
+type regexParser struct {
+	re     *regexp.Regexp
+	fields []string
+}
+
+func newRegexParser(pattern string) (*regexParser, error) {
+	var fields []string
+	expanded, err := expandGrok(pattern, &fields, 0)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, err
+	}
+
+	// Grok fields were given generated group names, since Go does not allow dots in them.
+	p := &regexParser{re: re, fields: re.SubexpNames()}
+	for i, name := range p.fields {
+		var n int
+		if _, err := fmt.Sscanf(name, "grok%d", &n); err == nil && n < len(fields) {
+			p.fields[i] = fields[n]
+		}
+	}
+	return p, nil
+}
+
+func expandGrok(pattern string, fields *[]string, depth int) (string, error) {
+	if depth > maxGrokDepth {
+		return "", fmt.Errorf("grok patterns nested too deeply in %q", pattern)
+	}
+	var expandErr error
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(reference string) string {
+		match := grokReference.FindStringSubmatch(reference)
+		definition, exists := grokPatterns[match[1]]
+		if !exists {
+			expandErr = fmt.Errorf("unknown grok pattern %s", match[1])
+			return ""
+		}
+		inner, err := expandGrok(definition, fields, depth+1)
+		if err != nil {
+			expandErr = err
+			return ""
+		}
+		if match[2] == "" {
+			return "(?:" + inner + ")"
+		}
+		*fields = append(*fields, match[2])
+		return fmt.Sprintf("(?P<grok%d>%s)", len(*fields)-1, inner)
+	})
+	return expanded, expandErr
+}
+
+// parse maps the fields captured from data onto logMessage: timestamp, level, message, trace_id
+// and span_id go to the matching fields, the others become attributes. It returns false when
+// data does not match.
+func (p *regexParser) parse(data string, timestamps *timestampParser, logMessage *LogMessage) bool {
+	match := p.re.FindStringSubmatch(data)
+	if match == nil {
+		return false
+	}
+	for i, field := range p.fields {
+		if field == "" || match[i] == "" {
+			continue
+		}
+		value := match[i]
+		switch field {
+		case "timestamp":
+			if timestamp, ok := timestamps.parse(value); ok {
+				logMessage.Timestamp = timestamp.UnixNano()
+			}
+		case "level":
+			logMessage.SeverityText = value
+			logMessage.SeverityNumber = severityNumber(value)
+		case "message":
+			logMessage.Message = value
+		case "trace_id":
+			logMessage.TraceID = value
+		case "span_id":
+			logMessage.SpanID = value
+		default:
+			logMessage.Attributes[field] = value
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package signoz
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestRegexParser(t *testing.T) {
+	p, err := newRegexParser(`^%{TIMESTAMP_ISO8601:timestamp} \[%{LOGLEVEL:level}\] trace=%{NOTSPACE:trace_id} %{IP:client.address} (?P<user>\w+) %{GREEDYDATA:message}$`)
+	if err != nil {
+		t.Fatalf("newRegexParser() error = %v", err)
+	}
+
+	data := "2024-05-01T10:00:00Z [WARN] trace=4bf92f3577b34da6a3ce929d0e0e4736 10.0.0.7 alice password about to expire"
+	logMessage := newTestParseMessage(data)
+	if !p.parse(data, nil, &logMessage) {
+		t.Fatal("parse() = false; want true")
+	}
+
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixNano(); logMessage.Timestamp != want {
+		t.Errorf("Timestamp = %d; want %d", logMessage.Timestamp, want)
+	}
+	if logMessage.SeverityText != "WARN" || logMessage.SeverityNumber != 13 {
+		t.Errorf("Severity = %s/%d; want WARN/13", logMessage.SeverityText, logMessage.SeverityNumber)
+	}
+	if logMessage.Message != "password about to expire" {
+		t.Errorf("Message = %q", logMessage.Message)
+	}
+	if logMessage.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("TraceID = %q", logMessage.TraceID)
+	}
+	want := map[string]string{"client.address": "10.0.0.7", "user": "alice"}
+	if !reflect.DeepEqual(logMessage.Attributes, want) {
+		t.Errorf("Attributes = %v; want %v", logMessage.Attributes, want)
+	}
+
+	if p.parse("no match here", nil, &logMessage) {
+		t.Error("parse() of a line that does not match = true")
+	}
+}
+
+func TestNewRegexParserErrors(t *testing.T) {
+	for _, pattern := range []string{`%{NOPE:x}`, `(unclosed`} {
+		if _, err := newRegexParser(pattern); err == nil {
+			t.Errorf("newRegexParser(%q) error = nil", pattern)
+		}
+	}
+}
+
+func TestRegexParserSelection(t *testing.T) {
+	if _, err := newAdapter(&router.Route{Options: map[string]string{"parser": "regex"}}); err == nil {
+		t.Error("newAdapter() with parser=regex and no pattern: error = nil")
+	}
+
+	a, err := newAdapter(&router.Route{Options: map[string]string{"parser": "regex", "parser.pattern": `^%{WORD:level} %{GREEDYDATA:message}`}})
+	if err != nil {
+		t.Fatalf("newAdapter() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		data        string
+		wantMessage string
+	}{
+		{"Route pattern", nil, "error disk full", "disk full"},
+		{"Label pattern", map[string]string{parserLabel: "regex", parserPatternLabel: `msg=%{GREEDYDATA:message}`}, "x msg=hello", "hello"},
+		{"Invalid label pattern uses the route pattern", map[string]string{parserLabel: "regex", parserPatternLabel: `(`}, "error disk full", "disk full"},
+		{"No match falls back to the line", nil, "!!!", "!!!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := &docker.Container{ID: tt.name, Config: &docker.Config{Labels: tt.labels}}
+			parser, pattern := a.parserFor(container)
+			logMessage := newTestParseMessage(tt.data)
+			a.parse(parser, pattern, nil, nil, tt.data, &logMessage)
+			if logMessage.Message != tt.wantMessage {
+				t.Errorf("Message = %q; want %q", logMessage.Message, tt.wantMessage)
+			}
+		})
+	}
+}
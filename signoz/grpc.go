@@ -0,0 +1,113 @@
+package signoz
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const grpcExportTimeout = 30 * time.Second
+
+// grpcExporter sends batches to the OTLP LogsService/Export RPC, usually exposed by the
+// collector on port 4317.
+type grpcExporter struct {
+	target string
+	conn   *grpc.ClientConn
+	client collogspb.LogsServiceClient
+	md     metadata.MD
+}
+
+// newGRPCExporter creates a gRPC exporter for endpoint. An https endpoint enables TLS, using
+// tlsConfig when it is not nil, and every header is sent as request metadata. gRPC only
+// supports gzip compression.
+func newGRPCExporter(endpoint string, tlsConfig *tls.Config, headers map[string]string, compression compression) (*grpcExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("signoz: invalid grpc endpoint %q: %w", endpoint, err)
+	}
+
+	creds := insecure.NewCredentials()
+	if u.Scheme == "https" {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	switch compression.name {
+	case compressionGzip:
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	case compressionZstd:
+		return nil, fmt.Errorf("signoz: zstd compression is not supported over grpc")
+	}
+
+	conn, err := grpc.NewClient(u.Host, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("signoz: cannot create grpc client for %q: %w", u.Host, err)
+	}
+
+	md := metadata.MD{}
+	for key, value := range headers {
+		md.Append(strings.ToLower(key), value)
+	}
+
+	return &grpcExporter{
+		target: u.Host,
+		conn:   conn,
+		client: collogspb.NewLogsServiceClient(conn),
+		md:     md,
+	}, nil
+}
+
+func (e *grpcExporter) export(logs []LogMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), grpcExportTimeout)
+	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, e.md)
+
+	if debug {
+		log.Printf("Sending logs to %s", e.target)
+	}
+	resp, err := e.client.Export(ctx, toOTLPRequest(logs))
+	if err != nil {
+		st := status.Convert(err)
+		exportErr := &exportError{
+			err:       fmt.Errorf("failed to send logs, status: %s: %s", st.Code(), st.Message()),
+			retryable: grpcRetryable(st.Code()),
+			response:  st.Message(),
+		}
+		for _, detail := range st.Details() {
+			if retryInfo, ok := detail.(*errdetails.RetryInfo); ok {
+				exportErr.retryAfter = retryInfo.GetRetryDelay().AsDuration()
+			}
+		}
+		return exportErr
+	}
+	reportPartialSuccess(e.target, len(logs), resp)
+	return nil
+}
+
+// grpcRetryable reports whether an export failing with code may succeed when retried,
+// following the OTLP specification.
+func grpcRetryable(code codes.Code) bool {
+	switch code {
+	case codes.Canceled, codes.DeadlineExceeded, codes.Aborted, codes.OutOfRange,
+		codes.Unavailable, codes.DataLoss, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
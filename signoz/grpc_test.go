@@ -0,0 +1,116 @@
+package signoz
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type mockLogsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	err      error
+	response *collogspb.ExportLogsServiceResponse
+	requests []*collogspb.ExportLogsServiceRequest
+	md       metadata.MD
+}
+
+func (s *mockLogsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.requests = append(s.requests, req)
+	if s.response != nil {
+		return s.response, nil
+	}
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func startMockLogsService(t *testing.T, service *mockLogsService) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, service)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return "http://" + listener.Addr().String()
+}
+
+func TestGRPCExporter(t *testing.T) {
+	service := &mockLogsService{}
+	endpoint := startMockLogsService(t, service)
+
+	exporter, err := newGRPCExporter(endpoint, nil, map[string]string{"Signoz-Ingestion-Key": "secret"}, compression{name: compressionGzip})
+	if err != nil {
+		t.Fatalf("newGRPCExporter() error = %v", err)
+	}
+
+	err = exporter.export([]LogMessage{{Timestamp: 1, Message: "hello", Resources: map[string]string{"service.name": "api"}}})
+	if err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	if len(service.requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(service.requests))
+	}
+	if got := service.md.Get("signoz-ingestion-key"); len(got) != 1 || got[0] != "secret" {
+		t.Errorf("Expected signoz-ingestion-key metadata: secret, got: %v", got)
+	}
+}
+
+func TestGRPCExporterRetryable(t *testing.T) {
+	tests := []struct {
+		code      codes.Code
+		retryable bool
+	}{
+		{codes.Unavailable, true},
+		{codes.ResourceExhausted, true},
+		{codes.InvalidArgument, false},
+		{codes.Unauthenticated, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			service := &mockLogsService{err: status.Error(tt.code, "mock failure")}
+			exporter, err := newGRPCExporter(startMockLogsService(t, service), nil, nil, compression{name: compressionNone})
+			if err != nil {
+				t.Fatalf("newGRPCExporter() error = %v", err)
+			}
+
+			err = exporter.export([]LogMessage{{Message: "hello"}})
+			var exportErr *exportError
+			if !errors.As(err, &exportErr) {
+				t.Fatalf("export() error = %v; want *exportError", err)
+			}
+			if exportErr.retryable != tt.retryable {
+				t.Errorf("retryable = %v; want %v", exportErr.retryable, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestGRPCExporterPartialSuccess(t *testing.T) {
+	service := &mockLogsService{response: &collogspb.ExportLogsServiceResponse{
+		PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1, ErrorMessage: "record too old"},
+	}}
+	exporter, err := newGRPCExporter(startMockLogsService(t, service), nil, nil, compression{name: compressionNone})
+	if err != nil {
+		t.Fatalf("newGRPCExporter() error = %v", err)
+	}
+
+	// The accepted records must not be sent again or dead-lettered.
+	if err := exporter.export([]LogMessage{{Message: "old"}, {Message: "new"}}); err != nil {
+		t.Errorf("export() error = %v; want nil for a partial success", err)
+	}
+	if len(service.requests) != 1 {
+		t.Errorf("Expected 1 request, got %d", len(service.requests))
+	}
+}
@@ -0,0 +1,201 @@
+package signoz
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+// debug logs every request to the collector. It is set by the DEBUG variable, like the debug
+// output of logspout itself.
+var debug = os.Getenv("DEBUG") != ""
+
+// maxResponseExcerpt is how much of an error response body is kept for the dead-letter sink,
+// and maxResponseBody how much of a successful one is read.
+const (
+	maxResponseExcerpt = 1024
+	maxResponseBody    = 64 << 10
+)
+
+// newHTTPClient returns the client of one adapter, with its own connection pool and timeouts
+// so a hung collector cannot block a flush forever. Requests go through the proxy set by the
+// http.proxy option, or by HTTP_PROXY, HTTPS_PROXY and NO_PROXY. tlsConfig may be nil for the
+// defaults.
+func newHTTPClient(route *router.Route, tlsConfig *tls.Config) (*http.Client, error) {
+	var err error
+	var timeout, dialTimeout, keepAlive, tlsHandshakeTimeout, responseHeaderTimeout, idleConnTimeout time.Duration
+	if timeout, err = getoptDuration(route, "http.timeout", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if dialTimeout, err = getoptDuration(route, "http.dial_timeout", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if keepAlive, err = getoptDuration(route, "http.keep_alive", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if tlsHandshakeTimeout, err = getoptDuration(route, "http.tls_handshake_timeout", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if responseHeaderTimeout, err = getoptDuration(route, "http.response_header_timeout", 20*time.Second); err != nil {
+		return nil, err
+	}
+	if idleConnTimeout, err = getoptDuration(route, "http.idle_conn_timeout", 90*time.Second); err != nil {
+		return nil, err
+	}
+
+	var maxIdleConns, maxIdleConnsPerHost, maxConnsPerHost int
+	if maxIdleConns, err = getoptInt(route, "http.max_idle_conns", 100); err != nil {
+		return nil, err
+	}
+	if maxIdleConnsPerHost, err = getoptInt(route, "http.max_idle_conns_per_host", 10); err != nil {
+		return nil, err
+	}
+	if maxConnsPerHost, err = getoptInt(route, "http.max_conns_per_host", 0); err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if proxyStr := getopt(route, "http.proxy", ""); proxyStr != "" {
+		proxyURL, err := url.Parse(proxyStr)
+		if err != nil {
+			return nil, fmt.Errorf("signoz: invalid http.proxy %q: %w", proxyStr, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: keepAlive}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		IdleConnTimeout:       idleConnTimeout,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		MaxConnsPerHost:       maxConnsPerHost,
+		ForceAttemptHTTP2:     true,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// httpSender posts encoded batches to a collector endpoint.
+type httpSender struct {
+	endpoint    string
+	client      *http.Client
+	headers     map[string]string
+	compression compression
+	// uncompressed is set once the collector answered 415 to a compressed request.
+	uncompressed atomic.Bool
+}
+
+// post sends data, compressed when configured. If the collector does not accept the
+// compressed body, the request is repeated uncompressed and compression stays off.
+func (s *httpSender) post(contentType string, data []byte) ([]byte, error) {
+	if !s.compression.enabled() || s.uncompressed.Load() {
+		return s.do(contentType, "", data)
+	}
+
+	compressed, err := s.compression.compress(data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := s.do(contentType, s.compression.name, compressed)
+	var exportErr *exportError
+	if errors.As(err, &exportErr) && exportErr.statusCode == http.StatusUnsupportedMediaType {
+		log.Printf("Collector at %s does not accept %s, sending uncompressed", s.endpoint, s.compression.name)
+		s.uncompressed.Store(true)
+		return s.do(contentType, "", data)
+	}
+	return body, err
+}
+
+func (s *httpSender) do(contentType, contentEncoding string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	// Send HTTP POST request
+	if debug {
+		log.Printf("Sending logs to %s", s.endpoint)
+	}
+	client := s.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &exportError{err: err, retryable: true}
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// checkResponse returns the body of a 2xx collector response and turns any other response
+// into an exportError.
+func checkResponse(resp *http.Response) ([]byte, error) {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+		io.Copy(io.Discard, resp.Body)
+		return body, nil
+	}
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseExcerpt))
+	io.Copy(io.Discard, resp.Body)
+	return nil, &exportError{
+		err:        fmt.Errorf("failed to send logs, status: %s", resp.Status),
+		retryable:  httpRetryable(resp.StatusCode),
+		statusCode: resp.StatusCode,
+		response:   string(excerpt),
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// httpRetryable reports whether a request failing with statusCode may succeed when retried.
+// Server errors, throttling and timeouts are retryable, other client errors such as 400, 401
+// and 403 are permanent.
+func httpRetryable(statusCode int) bool {
+	switch {
+	case statusCode >= 500:
+		return true
+	case statusCode == http.StatusTooManyRequests, statusCode == http.StatusRequestTimeout:
+		return true
+	}
+	return false
+}
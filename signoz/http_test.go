@@ -0,0 +1,78 @@
+package signoz
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	client, err := newHTTPClient(&router.Route{Options: map[string]string{
+		"http.timeout":                 "3s",
+		"http.max_idle_conns_per_host": "4",
+	}}, nil)
+	if err != nil {
+		t.Fatalf("newHTTPClient() error = %v", err)
+	}
+	if client.Timeout != 3*time.Second {
+		t.Errorf("Timeout = %s; want 3s", client.Timeout)
+	}
+	if transport := client.Transport.(*http.Transport); transport.MaxIdleConnsPerHost != 4 {
+		t.Errorf("MaxIdleConnsPerHost = %d; want 4", transport.MaxIdleConnsPerHost)
+	}
+
+	invalid := []map[string]string{
+		{"http.timeout": "soon"},
+		{"http.max_idle_conns": "many"},
+		{"http.proxy": "://proxy"},
+	}
+	for _, options := range invalid {
+		if _, err := newHTTPClient(&router.Route{Options: options}, nil); err == nil {
+			t.Errorf("newHTTPClient(%v) error = nil; want error", options)
+		}
+	}
+}
+
+func TestHTTPClientResponseHeaderTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	client, err := newHTTPClient(&router.Route{Options: map[string]string{"http.response_header_timeout": "50ms"}}, nil)
+	if err != nil {
+		t.Fatalf("newHTTPClient() error = %v", err)
+	}
+
+	exporter := &jsonExporter{&httpSender{endpoint: server.URL, client: client}}
+	err = exporter.export([]LogMessage{{Message: "hello"}})
+	if err == nil || !isRetryable(err) {
+		t.Errorf("export() error = %v; want retryable timeout", err)
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client, err := newHTTPClient(&router.Route{Options: map[string]string{"http.proxy": proxy.URL}}, nil)
+	if err != nil {
+		t.Fatalf("newHTTPClient() error = %v", err)
+	}
+
+	exporter := &jsonExporter{&httpSender{endpoint: "http://collector.invalid:8082/logs", client: client}}
+	if err := exporter.export([]LogMessage{{Message: "hello"}}); err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	if got := <-proxied; got != "http://collector.invalid:8082/logs" {
+		t.Errorf("Proxy received %s; want http://collector.invalid:8082/logs", got)
+	}
+}
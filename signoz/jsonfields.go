@@ -0,0 +1,165 @@
+package signoz
+
+import (
+	"encoding/json"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+// jsonFieldsLabelPrefix starts the labels that override the JSON keys of a field for a
+// container, e.g. signoz.json.message_key=msg.
+const jsonFieldsLabelPrefix = "signoz.json."
+
+// jsonFields maps each record field to the JSON keys it is read from, in order of preference.
+// A key may be a dotted path into nested objects, e.g. log.level; a literal key containing the
+// dots is preferred.
+type jsonFields map[string][]string
+
+// defaultJSONFields covers the keys written by common logging libraries: pino, zap, logrus,
+// bunyan, Serilog's compact format, python-json-logger and Elastic Common Schema.
+var defaultJSONFields = jsonFields{
+	"timestamp": {"timestamp", "@timestamp", "time", "ts", "@t"},
+	"level":     {"level", "lvl", "severity", "log.level", "levelname", "@l"},
+	"message":   {"message", "msg", "@m", "@mt"},
+	"service":   {"service", "service.name"},
+	"env":       {"env", "environment", "deployment.environment"},
+	"namespace": {"namespace"},
+	"trace_id":  {"trace_id", "traceId", "traceID", "trace.id"},
+	"span_id":   {"span_id", "spanId", "spanID", "span.id"},
+}
+
+// newJSONFields returns the default fields with the json.<field>_key options of route applied,
+// e.g. json.message_key=msg.
+func newJSONFields(route *router.Route) jsonFields {
+	return defaultJSONFields.override(func(option string) string { return getopt(route, "json."+option, "") })
+}
+
+// jsonFieldsFor returns the JSON fields of the route with the signoz.json.<field>_key labels of
+// container applied.
+func (a *Adapter) jsonFieldsFor(container *docker.Container) jsonFields {
+	return a.jsonFields.override(func(option string) string { return container.Config.Labels[jsonFieldsLabelPrefix+option] })
+}
+
+// override returns f with the keys of each field replaced by the list value returns for its
+// option, such as message_key, if any. f itself is not modified.
+func (f jsonFields) override(value func(option string) string) jsonFields {
+	var overridden jsonFields
+	for field := range f {
+		keys := splitList(value(field + "_key"))
+		if len(keys) == 0 {
+			continue
+		}
+		if overridden == nil {
+			overridden = make(jsonFields, len(f))
+			for field, keys := range f {
+				overridden[field] = keys
+			}
+		}
+		overridden[field] = keys
+	}
+	if overridden == nil {
+		return f
+	}
+	return overridden
+}
+
+// lookup returns the value of the first key of field found in jsonMap, along with the top-level
+// key it was found under when it was not nested.
+func (f jsonFields) lookup(jsonMap map[string]interface{}, field string) (interface{}, string, bool) {
+	for _, key := range f[field] {
+		if value, exists := jsonMap[key]; exists && value != nil {
+			return value, key, true
+		}
+		if value, exists := lookupPath(jsonMap, key); exists {
+			return value, "", true
+		}
+	}
+	return nil, "", false
+}
+
+// lookupPath follows a dotted path such as log.level into nested objects.
+func lookupPath(jsonMap map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	if len(parts) < 2 {
+		return nil, false
+	}
+	var value interface{} = jsonMap
+	for _, part := range parts {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[part]; !ok || value == nil {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// levelAliases are level names used by logging libraries that logLevelMap does not know.
+var levelAliases = map[string]string{
+	"VERBOSE":     "TRACE",
+	"INFORMATION": "INFO",
+	"NOTICE":      "INFO",
+	"ERR":         "ERROR",
+	"CRITICAL":    "FATAL",
+	"CRIT":        "FATAL",
+	"ALERT":       "FATAL",
+	"EMERGENCY":   "FATAL",
+	"PANIC":       "FATAL",
+	"DPANIC":      "FATAL",
+}
+
+// severityNumber returns the OTLP severity number of a level name, or 0 if it is unknown.
+func severityNumber(level string) int {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if alias, exists := levelAliases[level]; exists {
+		level = alias
+	}
+	return logLevelMap[level]
+}
+
+// numericLevel converts the numeric levels of pino and bunyan, 10 for trace up to 60 for fatal,
+// into a level name.
+func numericLevel(level float64) string {
+	switch {
+	case level >= 60:
+		return "fatal"
+	case level >= 50:
+		return "error"
+	case level >= 40:
+		return "warn"
+	case level >= 30:
+		return "info"
+	case level >= 20:
+		return "debug"
+	default:
+		return "trace"
+	}
+}
+
+// levelText returns a JSON level value as text, converting numeric levels.
+func levelText(value interface{}) (string, bool) {
+	switch level := value.(type) {
+	case string:
+		return level, true
+	case json.Number:
+		if number, err := level.Float64(); err == nil {
+			return numericLevel(number), true
+		}
+	}
+	return "", false
+}
+
+// timestampText returns a JSON timestamp value as text, keeping all digits of epoch numbers.
+func timestampText(value interface{}) (string, bool) {
+	switch timestamp := value.(type) {
+	case string:
+		return timestamp, true
+	case json.Number:
+		return timestamp.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,134 @@
+package signoz
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestParseJSONMessageFieldAliases(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixNano()
+	tests := []struct {
+		name           string
+		data           string
+		wantTimestamp  int64
+		wantLevel      string
+		wantNumber     int
+		wantMessage    string
+		wantAttributes map[string]string
+	}{
+		{
+			name:           "pino",
+			data:           `{"level":50,"time":"2024-05-01T10:00:00Z","msg":"query failed","pid":7}`,
+			wantTimestamp:  timestamp,
+			wantLevel:      "error",
+			wantNumber:     17,
+			wantMessage:    "query failed",
+			wantAttributes: map[string]string{"pid": "7"},
+		},
+		{
+			name:           "zap",
+			data:           `{"level":"warn","ts":"2024-05-01T10:00:00Z","msg":"slow request","caller":"main.go:12"}`,
+			wantTimestamp:  timestamp,
+			wantLevel:      "warn",
+			wantNumber:     13,
+			wantMessage:    "slow request",
+			wantAttributes: map[string]string{"caller": "main.go:12"},
+		},
+		{
+			name:           "ecs",
+			data:           `{"@timestamp":"2024-05-01T10:00:00Z","log":{"level":"error"},"message":"disk full"}`,
+			wantTimestamp:  timestamp,
+			wantLevel:      "error",
+			wantNumber:     17,
+			wantMessage:    "disk full",
+			wantAttributes: map[string]string{"log": "map[level:error]"},
+		},
+		{
+			name:           "serilog",
+			data:           `{"@t":"2024-05-01T10:00:00Z","@l":"Information","@m":"user signed in","UserId":42}`,
+			wantTimestamp:  timestamp,
+			wantLevel:      "Information",
+			wantNumber:     9,
+			wantMessage:    "user signed in",
+			wantAttributes: map[string]string{"UserId": "42"},
+		},
+	}
+	a := &Adapter{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logMessage := newTestParseMessage(tt.data)
+			if !a.parseJSONMessage(tt.data, nil, nil, &logMessage) {
+				t.Fatal("parseJSONMessage() = false; want true")
+			}
+			if logMessage.Timestamp != tt.wantTimestamp {
+				t.Errorf("Timestamp = %d; want %d", logMessage.Timestamp, tt.wantTimestamp)
+			}
+			if logMessage.SeverityText != tt.wantLevel || logMessage.SeverityNumber != tt.wantNumber {
+				t.Errorf("Severity = %s/%d; want %s/%d", logMessage.SeverityText, logMessage.SeverityNumber, tt.wantLevel, tt.wantNumber)
+			}
+			if logMessage.Message != tt.wantMessage {
+				t.Errorf("Message = %q; want %q", logMessage.Message, tt.wantMessage)
+			}
+			if !reflect.DeepEqual(logMessage.Attributes, tt.wantAttributes) {
+				t.Errorf("Attributes = %v; want %v", logMessage.Attributes, tt.wantAttributes)
+			}
+		})
+	}
+}
+
+func TestJSONFieldsOverrides(t *testing.T) {
+	route := &router.Route{Options: map[string]string{"json.message_key": "text|body"}}
+	a := &Adapter{jsonFields: newJSONFields(route)}
+
+	if keys := a.jsonFields["message"]; !reflect.DeepEqual(keys, []string{"text", "body"}) {
+		t.Errorf("route message keys = %v; want [text body]", keys)
+	}
+	if keys := defaultJSONFields["message"]; keys[0] != "message" {
+		t.Errorf("defaults modified by override: %v", keys)
+	}
+
+	container := &docker.Container{Config: &docker.Config{Labels: map[string]string{
+		"signoz.json.level_key":   "meta.severity",
+		"signoz.json.service_key": "app",
+	}}}
+	fields := a.jsonFieldsFor(container)
+
+	data := `{"text":"hello","meta":{"severity":"debug"},"app":"billing","level":"info"}`
+	logMessage := newTestParseMessage(data)
+	a.parseJSONMessage(data, fields, nil, &logMessage)
+	if logMessage.Message != "hello" {
+		t.Errorf("Message = %q; want hello", logMessage.Message)
+	}
+	if logMessage.SeverityText != "debug" || logMessage.SeverityNumber != 5 {
+		t.Errorf("Severity = %s/%d; want debug/5", logMessage.SeverityText, logMessage.SeverityNumber)
+	}
+	if logMessage.Resources["service.name"] != "billing" {
+		t.Errorf("service.name = %q; want billing", logMessage.Resources["service.name"])
+	}
+	// level is no longer mapped for this container, so it is kept as an attribute.
+	if logMessage.Attributes["level"] != "info" {
+		t.Errorf("Attributes = %v; want level kept", logMessage.Attributes)
+	}
+}
+
+func TestJSONFieldsLookupPrefersLiteralKey(t *testing.T) {
+	jsonMap := map[string]interface{}{
+		"log.level": "warn",
+		"log":       map[string]interface{}{"level": "error"},
+	}
+	fields := jsonFields{"level": {"log.level"}}
+	value, key, found := fields.lookup(jsonMap, "level")
+	if !found || value != "warn" || key != "log.level" {
+		t.Errorf("lookup() = %v, %q, %v; want warn, log.level, true", value, key, found)
+	}
+
+	delete(jsonMap, "log.level")
+	value, key, found = fields.lookup(jsonMap, "level")
+	if !found || value != "error" || key != "" {
+		t.Errorf("lookup() = %v, %q, %v; want error, \"\", true", value, key, found)
+	}
+}
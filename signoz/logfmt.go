@@ -0,0 +1,90 @@
+package signoz
+
+import (
+	"strconv"
+	"strings"
+)
+
+// logfmtPair is one key=value pair of a logfmt line.
+type logfmtPair struct {
+	key, value string
+}
+
+// parseLogfmt splits a logfmt line such as `level=warn msg="cache miss" key=abc` into its pairs,
+// in order. Values may be quoted with Go escapes. It returns false when data is not logfmt: when
+// it contains a word without "=", a broken quote or fewer than two pairs, so plain text that
+// happens to contain an "=" is left alone.
+func parseLogfmt(data string) ([]logfmtPair, bool) {
+	var pairs []logfmtPair
+	rest := strings.TrimSpace(data)
+	for rest != "" {
+		eq := strings.IndexAny(rest, "= \t\"")
+		if eq <= 0 || rest[eq] != '=' {
+			return nil, false
+		}
+		pair := logfmtPair{key: rest[:eq]}
+		rest = rest[eq+1:]
+
+		if strings.HasPrefix(rest, `"`) {
+			end := 1
+			for end < len(rest) && rest[end] != '"' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(rest) {
+				return nil, false
+			}
+			value, err := strconv.Unquote(rest[:end+1])
+			if err != nil {
+				return nil, false
+			}
+			pair.value, rest = value, rest[end+1:]
+			if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+				return nil, false
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			pair.value, rest = rest[:end], rest[end:]
+		}
+
+		pairs = append(pairs, pair)
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	return pairs, len(pairs) >= 2
+}
+
+// parseLogfmtMessage maps the pairs of a logfmt line onto logMessage the same way JSON fields are
+// mapped: level, msg, time or ts, service and env. Other keys become attributes. It returns false
+// when data is not logfmt.
+func parseLogfmtMessage(data string, timestamps *timestampParser, logMessage *LogMessage) bool {
+	pairs, ok := parseLogfmt(data)
+	if !ok {
+		return false
+	}
+
+	for _, pair := range pairs {
+		switch pair.key {
+		case "level":
+			logMessage.SeverityText = pair.value
+			logMessage.SeverityNumber = severityNumber(pair.value)
+		case "msg":
+			logMessage.Message = pair.value
+		case "time", "ts":
+			if timestamp, ok := timestamps.parse(pair.value); ok {
+				logMessage.Timestamp = timestamp.UnixNano()
+			}
+		case "service":
+			logMessage.Resources["service.name"] = pair.value
+		case "env":
+			logMessage.Resources["deployment.environment"] = pair.value
+		default:
+			logMessage.Attributes[pair.key] = pair.value
+		}
+	}
+	return true
+}
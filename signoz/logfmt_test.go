@@ -0,0 +1,65 @@
+package signoz
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   []logfmtPair
+		wantOk bool
+	}{
+		{`level=warn msg="cache miss" key=abc`, []logfmtPair{{"level", "warn"}, {"msg", "cache miss"}, {"key", "abc"}}, true},
+		{`msg="say \"hi\"" empty= n=1`, []logfmtPair{{"msg", `say "hi"`}, {"empty", ""}, {"n", "1"}}, true},
+		{`  a=1   b=2  `, []logfmtPair{{"a", "1"}, {"b", "2"}}, true},
+		{`Listening on port=8080`, nil, false},
+		{`only=one`, nil, false},
+		{`msg="unterminated level=info`, nil, false},
+		{`msg="x"y level=info`, nil, false},
+		{`=value a=1`, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLogfmt(tt.input)
+		if ok != tt.wantOk || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("parseLogfmt(%q) = %v, %v; want %v, %v", tt.input, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestParseLogfmtMessage(t *testing.T) {
+	data := `time=2024-05-01T10:00:00Z level=warn msg="cache miss" service=api env=prod key=abc`
+	logMessage := newTestParseMessage(data)
+	if !parseLogfmtMessage(data, nil, &logMessage) {
+		t.Fatal("parseLogfmtMessage() = false; want true")
+	}
+
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixNano()
+	if logMessage.Timestamp != want {
+		t.Errorf("Timestamp = %d; want %d", logMessage.Timestamp, want)
+	}
+	if logMessage.SeverityText != "warn" || logMessage.SeverityNumber != 13 {
+		t.Errorf("Severity = %s/%d; want warn/13", logMessage.SeverityText, logMessage.SeverityNumber)
+	}
+	if logMessage.Message != "cache miss" {
+		t.Errorf("Message = %q; want %q", logMessage.Message, "cache miss")
+	}
+	if logMessage.Resources["service.name"] != "api" || logMessage.Resources["deployment.environment"] != "prod" {
+		t.Errorf("Resources = %v", logMessage.Resources)
+	}
+	if !reflect.DeepEqual(logMessage.Attributes, map[string]string{"key": "abc"}) {
+		t.Errorf("Attributes = %v; want only key", logMessage.Attributes)
+	}
+}
+
+func TestParseAutoDetectsLogfmt(t *testing.T) {
+	a := &Adapter{autoParseJson: true, autoLogLevelStringMatch: true}
+	data := `level=error msg="connection refused" host=db`
+	logMessage := newTestParseMessage(data)
+	a.parse(parserAuto, nil, nil, nil, data, &logMessage)
+	if logMessage.Message != "connection refused" || logMessage.SeverityText != "error" || logMessage.Attributes["host"] != "db" {
+		t.Errorf("parse() = %+v", logMessage)
+	}
+}
@@ -0,0 +1,7 @@
+package signoz
+
+import "expvar"
+
+// metrics holds the adapter counters. logspout serves the default HTTP mux, so they are
+// available as JSON under /debug/vars on the logspout HTTP port.
+var metrics = expvar.NewMap("signoz")
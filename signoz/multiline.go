@@ -0,0 +1,220 @@
+package signoz
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+const (
+	multilineNone = "none"
+
+	// multilineLabel selects the multiline rule for the logs of a container, overriding the
+	// multiline option. multilineStartLabel and multilineContinueLabel set custom regexes.
+	multilineLabel         = "signoz.multiline"
+	multilineStartLabel    = "signoz.multiline.start"
+	multilineContinueLabel = "signoz.multiline.continue"
+)
+
+// multilineRule decides which lines belong to the event before them. A line continues the
+// current event when it matches continueLine, or when only startLine is set and it does not
+// match startLine.
+type multilineRule struct {
+	startLine    *regexp.Regexp
+	continueLine *regexp.Regexp
+}
+
+func (r *multilineRule) continues(line string) bool {
+	if r.continueLine != nil && r.continueLine.MatchString(line) {
+		return true
+	}
+	return r.continueLine == nil && r.startLine != nil && !r.startLine.MatchString(line)
+}
+
+// multilineRules are the built-in rules. They recognize the continuation lines of stack traces,
+// so everything else still starts a new event.
+var multilineRules = map[string]*multilineRule{
+	// The exception line after the logged message, indented "at ..." frames, "... 12 more",
+	// "Caused by:" and "Suppressed:".
+	"java": {continueLine: regexp.MustCompile(`^(\s+\S|\s*Caused by:|\s*Suppressed:|[\w.$]+(Exception|Error|Throwable)(:|$))`)},
+	// The traceback header after the logged message, indented frames and source lines, the
+	// exception line closing a traceback and chained tracebacks.
+	"python": {continueLine: regexp.MustCompile(`^(\s+\S|\s*$|Traceback \(most recent call last\):|[\w.]+(Error|Exception|Exit|Interrupt|Warning)\b|During handling of the above exception|The above exception was the direct cause)`)},
+	// Goroutine headers, function and indented file lines, blank lines and the runtime's trailers.
+	"go": {continueLine: regexp.MustCompile(`^(\s+\S|\s*$|goroutine \d+ \[|[\w./*()\-]+\(.*\)$|created by |\[signal |exit status \d+)`)},
+	// Indented "at ..." frames and the caret under the failing source line.
+	"node": {continueLine: regexp.MustCompile(`^\s+(at |\.\.\. \d+ more|\^+\s*$)`)},
+}
+
+func init() {
+	auto := make([]string, 0, len(multilineRules))
+	for _, rule := range multilineRules {
+		auto = append(auto, rule.continueLine.String())
+	}
+	multilineRules["auto"] = &multilineRule{continueLine: regexp.MustCompile(strings.Join(auto, "|"))}
+}
+
+// multilineIdle is how long the rule of a container that logs nothing is kept, so the rules
+// of removed containers are not kept forever.
+const multilineIdle = 10 * time.Minute
+
+// multilineConfig joins the lines of an event, such as a stack trace, into a single message.
+// An event is complete when a line starts the next one, when no line arrived for timeout, or
+// when it reached maxLines lines or maxBytes bytes.
+type multilineConfig struct {
+	rule     string
+	timeout  time.Duration
+	maxLines int
+	maxBytes int
+}
+
+func newMultilineConfig(route *router.Route) (multilineConfig, error) {
+	c := multilineConfig{rule: strings.ToLower(getopt(route, "multiline", multilineNone))}
+	if _, exists := multilineRules[c.rule]; !exists && c.rule != multilineNone {
+		return c, fmt.Errorf("signoz: invalid multiline %q", c.rule)
+	}
+	var err error
+	if c.timeout, err = getoptDuration(route, "multiline.timeout", time.Second); err != nil {
+		return c, err
+	}
+	if c.maxLines, err = getoptInt(route, "multiline.max_lines", 500); err != nil {
+		return c, err
+	}
+	maxBytes, err := getoptSize(route, "multiline.max_bytes", 64<<10)
+	if err != nil {
+		return c, err
+	}
+	c.maxBytes = int(maxBytes)
+	if c.timeout <= 0 {
+		c.timeout = time.Second
+	}
+	return c, nil
+}
+
+// ruleFor returns the multiline rule for container, or nil when its lines are not joined.
+// Custom regexes in its labels take precedence over a rule named by label or route option.
+func (c multilineConfig) ruleFor(container *docker.Container) (*multilineRule, error) {
+	labels := container.Config.Labels
+	if labels[multilineStartLabel] != "" || labels[multilineContinueLabel] != "" {
+		rule := &multilineRule{}
+		var err error
+		if pattern := labels[multilineStartLabel]; pattern != "" {
+			if rule.startLine, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", multilineStartLabel, err)
+			}
+		}
+		if pattern := labels[multilineContinueLabel]; pattern != "" {
+			if rule.continueLine, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", multilineContinueLabel, err)
+			}
+		}
+		return rule, nil
+	}
+
+	name := c.rule
+	if label, exists := labels[multilineLabel]; exists {
+		name = strings.ToLower(strings.TrimSpace(label))
+	}
+	if name == multilineNone {
+		return nil, nil
+	}
+	rule, exists := multilineRules[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown %s %q", multilineLabel, name)
+	}
+	return rule, nil
+}
+
+// containerRule is the rule of a container and when it last logged.
+type containerRule struct {
+	rule *multilineRule
+	last time.Time
+}
+
+// multilineEvent is an event still collecting lines.
+type multilineEvent struct {
+	message *router.Message
+	lines   int
+	last    time.Time
+}
+
+// aggregate returns a stream with the lines of in joined into events per container. Messages of
+// containers without a multiline rule pass straight through. Pending events are sent when in
+// is closed, or when stop is closed, which also ends the stream while in may still be open.
+func (c multilineConfig) aggregate(in chan *router.Message, stop <-chan struct{}) chan *router.Message {
+	out := make(chan *router.Message)
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(c.timeout / 2)
+		defer ticker.Stop()
+
+		rules := make(map[string]*containerRule)
+		pending := make(map[string]*multilineEvent)
+		for {
+			select {
+			case <-stop:
+				for _, event := range pending {
+					out <- event.message
+				}
+				return
+
+			case message, ok := <-in:
+				if !ok {
+					for _, event := range pending {
+						out <- event.message
+					}
+					return
+				}
+
+				id := message.Container.ID
+				known := rules[id]
+				if known == nil {
+					rule, err := c.ruleFor(message.Container)
+					if err != nil {
+						log.Printf("Not joining multiline logs of container %s: %v", message.Container.Name, err)
+					}
+					known = &containerRule{rule: rule}
+					rules[id] = known
+				}
+				known.last = time.Now()
+				rule := known.rule
+				if rule == nil {
+					out <- message
+					continue
+				}
+
+				event := pending[id]
+				if event != nil && rule.continues(message.Data) && event.lines < c.maxLines && len(event.message.Data)+1+len(message.Data) <= c.maxBytes {
+					event.message.Data += "\n" + message.Data
+					event.lines++
+					event.last = time.Now()
+					continue
+				}
+				if event != nil {
+					out <- event.message
+				}
+				joined := *message
+				pending[id] = &multilineEvent{message: &joined, lines: 1, last: time.Now()}
+
+			case now := <-ticker.C:
+				for id, event := range pending {
+					if now.Sub(event.last) >= c.timeout {
+						out <- event.message
+						delete(pending, id)
+					}
+				}
+				for id, known := range rules {
+					if now.Sub(known.last) >= multilineIdle {
+						delete(rules, id)
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
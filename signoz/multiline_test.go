@@ -0,0 +1,179 @@
+package signoz
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+// aggregateLines runs lines of one container with the given labels through aggregate and
+// returns the resulting messages.
+func aggregateLines(t *testing.T, c multilineConfig, labels map[string]string, lines ...string) []string {
+	container := &docker.Container{ID: "abc", Config: &docker.Config{Labels: labels}}
+	in := make(chan *router.Message, len(lines))
+	for _, line := range lines {
+		in <- &router.Message{Container: container, Data: line, Time: time.Now()}
+	}
+	close(in)
+
+	var got []string
+	for message := range c.aggregate(in, nil) {
+		got = append(got, message.Data)
+	}
+	return got
+}
+
+func TestMultilineBuiltinRules(t *testing.T) {
+	tests := []struct {
+		rule  string
+		lines []string
+		want  int
+	}{
+		{"java", []string{
+			"Exception in thread \"main\" java.lang.IllegalStateException: boom",
+			"\tat com.example.App.run(App.java:10)",
+			"\tat com.example.App.main(App.java:5)",
+			"Caused by: java.io.IOException: disk",
+			"\t... 2 more",
+			"next log line",
+		}, 2},
+		{"java", []string{
+			"2024-05-01 10:00:00 ERROR OrderService - Failed to process order",
+			"java.lang.IllegalStateException: boom",
+			"\tat com.example.OrderService.process(OrderService.java:42)",
+			"Caused by: java.io.IOException: disk",
+			"\t... 2 more",
+			"2024-05-01 10:00:01 INFO OrderService - next order",
+		}, 2},
+		{"python", []string{
+			"Traceback (most recent call last):",
+			"  File \"app.py\", line 3, in <module>",
+			"    main()",
+			"ValueError: bad value",
+			"next log line",
+		}, 2},
+		{"python", []string{
+			"ERROR:root:Failed to process order",
+			"Traceback (most recent call last):",
+			"  File \"app.py\", line 3, in <module>",
+			"    process()",
+			"ValueError: bad value",
+			"INFO:root:next order",
+		}, 2},
+		{"go", []string{
+			"panic: runtime error: index out of range",
+			"",
+			"goroutine 1 [running]:",
+			"main.main()",
+			"\t/app/main.go:8 +0x1d",
+			"exit status 2",
+			"next log line",
+		}, 2},
+		{"node", []string{
+			"Error: connect ECONNREFUSED",
+			"    at TCPConnectWrap.afterConnect (node:net:1494:16)",
+			"    at process.processTicksAndRejections (node:internal/process/task_queues:82:21)",
+			"next log line",
+		}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			c, err := newMultilineConfig(&router.Route{Options: map[string]string{"multiline": tt.rule}})
+			if err != nil {
+				t.Fatalf("newMultilineConfig() error = %v", err)
+			}
+			got := aggregateLines(t, c, nil, tt.lines...)
+			if len(got) != tt.want {
+				t.Fatalf("aggregate() = %q; want %d events", got, tt.want)
+			}
+			if got[0] != strings.Join(tt.lines[:len(tt.lines)-1], "\n") {
+				t.Errorf("aggregate() first event = %q", got[0])
+			}
+		})
+	}
+}
+
+func TestMultilineLabels(t *testing.T) {
+	c, err := newMultilineConfig(&router.Route{})
+	if err != nil {
+		t.Fatalf("newMultilineConfig() error = %v", err)
+	}
+
+	got := aggregateLines(t, c, nil, "first", "  second")
+	if len(got) != 2 {
+		t.Errorf("aggregate() without rule = %q; want lines unchanged", got)
+	}
+
+	got = aggregateLines(t, c, map[string]string{multilineLabel: "java"}, "first", "  second")
+	if len(got) != 1 {
+		t.Errorf("aggregate() with %s label = %q; want one event", multilineLabel, got)
+	}
+
+	got = aggregateLines(t, c, map[string]string{multilineStartLabel: `^\d{4}-\d{2}-\d{2} `},
+		"2024-05-01 first", "detail", "more detail", "2024-05-01 second")
+	if len(got) != 2 || got[0] != "2024-05-01 first\ndetail\nmore detail" {
+		t.Errorf("aggregate() with %s label = %q", multilineStartLabel, got)
+	}
+
+	got = aggregateLines(t, c, map[string]string{multilineContinueLabel: `^\|`}, "table", "| a", "| b", "after")
+	if len(got) != 2 || got[0] != "table\n| a\n| b" {
+		t.Errorf("aggregate() with %s label = %q", multilineContinueLabel, got)
+	}
+
+	got = aggregateLines(t, c, map[string]string{multilineStartLabel: `(`}, "first", "second")
+	if len(got) != 2 {
+		t.Errorf("aggregate() with an invalid regex = %q; want lines unchanged", got)
+	}
+}
+
+func TestMultilineLimits(t *testing.T) {
+	c, err := newMultilineConfig(&router.Route{Options: map[string]string{"multiline": "java", "multiline.max_lines": "3"}})
+	if err != nil {
+		t.Fatalf("newMultilineConfig() error = %v", err)
+	}
+	got := aggregateLines(t, c, nil, "start", " 1", " 2", " 3", " 4")
+	if len(got) != 2 || got[0] != "start\n 1\n 2" {
+		t.Errorf("aggregate() with max_lines = %q", got)
+	}
+
+	c, err = newMultilineConfig(&router.Route{Options: map[string]string{"multiline": "java", "multiline.max_bytes": "12"}})
+	if err != nil {
+		t.Fatalf("newMultilineConfig() error = %v", err)
+	}
+	got = aggregateLines(t, c, nil, "start", " 1234", " 5678")
+	if len(got) != 2 || got[0] != "start\n 1234" {
+		t.Errorf("aggregate() with max_bytes = %q", got)
+	}
+}
+
+func TestMultilineTimeout(t *testing.T) {
+	c, err := newMultilineConfig(&router.Route{Options: map[string]string{"multiline": "java", "multiline.timeout": "50ms"}})
+	if err != nil {
+		t.Fatalf("newMultilineConfig() error = %v", err)
+	}
+	container := &docker.Container{ID: "abc", Config: &docker.Config{}}
+	in := make(chan *router.Message)
+	out := c.aggregate(in, nil)
+	defer close(in)
+
+	in <- &router.Message{Container: container, Data: "start"}
+	in <- &router.Message{Container: container, Data: "  frame"}
+	select {
+	case message := <-out:
+		if message.Data != "start\n  frame" {
+			t.Errorf("Flushed event = %q", message.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Pending event was not flushed after the timeout")
+	}
+}
+
+func TestNewMultilineConfigInvalidRule(t *testing.T) {
+	if _, err := newMultilineConfig(&router.Route{Options: map[string]string{"multiline": "cobol"}}); err == nil {
+		t.Error("newMultilineConfig() with an unknown rule: error = nil")
+	}
+}
@@ -0,0 +1,115 @@
+package signoz
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+// optionEnv returns the environment variable that backs a route option,
+// e.g. retry.max_attempts is read from SIGNOZ_RETRY_MAX_ATTEMPTS.
+func optionEnv(key string) string {
+	return "SIGNOZ_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// routeKey returns a short stable name for a route derived from its adapter and address. It
+// keeps the files of several routes apart when they share a directory.
+func routeKey(route *router.Route) string {
+	h := sha1.New()
+	io.WriteString(h, route.Adapter+"://"+route.Address)
+	return fmt.Sprintf("%x", h.Sum(nil))[:12]
+}
+
+// getopt returns the route option key. When the route does not set it the matching
+// SIGNOZ_* environment variable is used, and dfault when neither is set.
+func getopt(route *router.Route, key, dfault string) string {
+	if value := route.Options[key]; value != "" {
+		return value
+	}
+	if value := os.Getenv(optionEnv(key)); value != "" {
+		return value
+	}
+	return dfault
+}
+
+func getoptInt(route *router.Route, key string, dfault int) (int, error) {
+	value := getopt(route, key, "")
+	if value == "" {
+		return dfault, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("signoz: invalid %s %q: %w", key, value, err)
+	}
+	return i, nil
+}
+
+func getoptFloat(route *router.Route, key string, dfault float64) (float64, error) {
+	value := getopt(route, key, "")
+	if value == "" {
+		return dfault, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("signoz: invalid %s %q: %w", key, value, err)
+	}
+	return f, nil
+}
+
+func getoptDuration(route *router.Route, key string, dfault time.Duration) (time.Duration, error) {
+	value := getopt(route, key, "")
+	if value == "" {
+		return dfault, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("signoz: invalid %s %q: %w", key, value, err)
+	}
+	return d, nil
+}
+
+// getoptSize reads a byte size such as 1048576, 512KB, 16MB or 1GB. Units are powers of 1024.
+func getoptSize(route *router.Route, key string, dfault int64) (int64, error) {
+	value := getopt(route, key, "")
+	if value == "" {
+		return dfault, nil
+	}
+	size, err := parseSize(value)
+	if err != nil {
+		return 0, fmt.Errorf("signoz: invalid %s %q: %w", key, value, err)
+	}
+	return size, nil
+}
+
+func parseSize(value string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30}, {"G", 1 << 30},
+		{"MB", 1 << 20}, {"M", 1 << 20},
+		{"KB", 1 << 10}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	number, multiplier := strings.ToUpper(strings.TrimSpace(value)), int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, multiplier = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.multiplier
+			break
+		}
+	}
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if size < 0 {
+		return 0, fmt.Errorf("size must not be negative")
+	}
+	return size * multiplier, nil
+}
@@ -0,0 +1,130 @@
+package signoz
+
+import (
+	"encoding/hex"
+	"log"
+	"sort"
+	"strings"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const otlpScopeName = "github.com/pavanputhra/logspout-signoz"
+
+// toOTLPRequest converts a batch into an OTLP ExportLogsServiceRequest. Messages that share
+// the same resources are grouped under a single ResourceLogs entry, in order of first appearance.
+func toOTLPRequest(logs []LogMessage) *collogspb.ExportLogsServiceRequest {
+	request := &collogspb.ExportLogsServiceRequest{}
+	byResource := make(map[string]*logspb.ScopeLogs)
+
+	for _, logMessage := range logs {
+		key := resourceKey(logMessage.Resources)
+		scopeLogs, exists := byResource[key]
+		if !exists {
+			scopeLogs = &logspb.ScopeLogs{
+				Scope: &commonpb.InstrumentationScope{Name: otlpScopeName},
+			}
+			request.ResourceLogs = append(request.ResourceLogs, &logspb.ResourceLogs{
+				Resource:  &resourcepb.Resource{Attributes: toKeyValues(logMessage.Resources)},
+				ScopeLogs: []*logspb.ScopeLogs{scopeLogs},
+			})
+			byResource[key] = scopeLogs
+		}
+		scopeLogs.LogRecords = append(scopeLogs.LogRecords, toLogRecord(logMessage))
+	}
+	return request
+}
+
+func toLogRecord(logMessage LogMessage) *logspb.LogRecord {
+	timeUnixNano := uint64(logMessage.Timestamp)
+	return &logspb.LogRecord{
+		TimeUnixNano:         timeUnixNano,
+		ObservedTimeUnixNano: timeUnixNano,
+		SeverityNumber:       logspb.SeverityNumber(logMessage.SeverityNumber),
+		SeverityText:         logMessage.SeverityText,
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: logMessage.Message}},
+		Attributes:           toKeyValues(logMessage.Attributes),
+		TraceId:              decodeID(logMessage.TraceID, 16),
+		SpanId:               decodeID(logMessage.SpanID, 8),
+	}
+}
+
+// decodeID decodes a hex trace or span ID of size bytes. IDs that are not valid are left out,
+// as collectors reject the whole request for them.
+func decodeID(id string, size int) []byte {
+	decoded, err := hex.DecodeString(id)
+	if err != nil || len(decoded) != size {
+		return nil
+	}
+	return decoded
+}
+
+// toKeyValues converts a string map into OTLP attributes sorted by key, so that the
+// encoded payload is stable.
+func toKeyValues(values map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	keyValues := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		keyValues = append(keyValues, &commonpb.KeyValue{
+			Key:   key,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: values[key]}},
+		})
+	}
+	return keyValues
+}
+
+func resourceKey(resources map[string]string) string {
+	var key strings.Builder
+	for _, keyValue := range toKeyValues(resources) {
+		key.WriteString(keyValue.Key)
+		key.WriteByte('=')
+		key.WriteString(keyValue.Value.GetStringValue())
+		key.WriteByte(0)
+	}
+	return key.String()
+}
+
+// otlpHTTPExporter posts batches as OTLP/HTTP protobuf, which any stock otel-collector
+// accepts on its otlp receiver.
+type otlpHTTPExporter struct {
+	*httpSender
+}
+
+func (e *otlpHTTPExporter) export(logs []LogMessage) error {
+	data, err := proto.Marshal(toOTLPRequest(logs))
+	if err != nil {
+		return err
+	}
+	body, err := e.post("application/x-protobuf", data)
+	if err != nil {
+		return err
+	}
+	// An empty body is a full success. A body that does not decode is not worth failing an
+	// accepted batch over.
+	var resp collogspb.ExportLogsServiceResponse
+	if err := proto.Unmarshal(body, &resp); err != nil {
+		log.Printf("Collector %s sent an undecodable response: %v", e.endpoint, err)
+		return nil
+	}
+	reportPartialSuccess(e.endpoint, len(logs), &resp)
+	return nil
+}
+
+// reportPartialSuccess logs and counts the records a collector rejected from an otherwise
+// accepted batch. Failing the batch would export the accepted records again or dead-letter
+// them, so the rejected ones are not retried.
+func reportPartialSuccess(target string, logs int, resp *collogspb.ExportLogsServiceResponse) {
+	if partial := resp.GetPartialSuccess(); partial != nil && partial.GetRejectedLogRecords() > 0 {
+		metrics.Add("records_rejected", partial.GetRejectedLogRecords())
+		log.Printf("Collector %s rejected %d of %d log records: %s", target, partial.GetRejectedLogRecords(), logs, partial.GetErrorMessage())
+	}
+}
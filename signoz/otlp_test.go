@@ -0,0 +1,107 @@
+package signoz
+
+import (
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestToOTLPRequest(t *testing.T) {
+	logs := []LogMessage{
+		{Timestamp: 10e9, SeverityText: "info", SeverityNumber: 9, Message: "first", Resources: map[string]string{"service.name": "api"}, Attributes: map[string]string{"foo": "bar"}},
+		{Timestamp: 11e9, SeverityText: "error", SeverityNumber: 17, Message: "second", Resources: map[string]string{"service.name": "db"}, Attributes: map[string]string{}},
+		{Timestamp: 12e9, SeverityText: "warn", SeverityNumber: 13, Message: "third", Resources: map[string]string{"service.name": "api"}, Attributes: map[string]string{}},
+	}
+
+	request := toOTLPRequest(logs)
+	if len(request.ResourceLogs) != 2 {
+		t.Fatalf("Expected 2 resource logs, got %d", len(request.ResourceLogs))
+	}
+
+	apiRecords := request.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(apiRecords) != 2 {
+		t.Fatalf("Expected 2 records for api, got %d", len(apiRecords))
+	}
+	if apiRecords[0].TimeUnixNano != 10e9 {
+		t.Errorf("Expected time_unix_nano: 10e9, got: %d", apiRecords[0].TimeUnixNano)
+	}
+	if apiRecords[0].Body.GetStringValue() != "first" {
+		t.Errorf("Expected body: first, got: %s", apiRecords[0].Body.GetStringValue())
+	}
+	if apiRecords[0].Attributes[0].Key != "foo" || apiRecords[0].Attributes[0].Value.GetStringValue() != "bar" {
+		t.Errorf("Expected attribute foo=bar, got: %v", apiRecords[0].Attributes)
+	}
+	if apiRecords[1].SeverityNumber != 13 {
+		t.Errorf("Expected severity_number: 13, got: %d", apiRecords[1].SeverityNumber)
+	}
+
+	resource := request.ResourceLogs[1].Resource.Attributes[0]
+	if resource.Key != "service.name" || resource.Value.GetStringValue() != "db" {
+		t.Errorf("Expected resource service.name=db, got: %v", resource)
+	}
+}
+
+func TestOTLPHTTPExporter(t *testing.T) {
+	var received collogspb.ExportLogsServiceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" {
+			t.Errorf("Expected path /v1/logs, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("Expected Content-Type application/x-protobuf, got %s", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := proto.Unmarshal(body, &received); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := &otlpHTTPExporter{&httpSender{endpoint: server.URL + "/v1/logs"}}
+	err := exporter.export([]LogMessage{{Timestamp: 1, Message: "hello", Resources: map[string]string{"service.name": "api"}}})
+	if err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	if len(received.ResourceLogs) != 1 {
+		t.Fatalf("Expected 1 resource logs, got %d", len(received.ResourceLogs))
+	}
+}
+
+func TestOTLPHTTPExporterPartialSuccess(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := proto.Marshal(&collogspb.ExportLogsServiceResponse{
+			PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1, ErrorMessage: "record too old"},
+		})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	rejected := func() int64 {
+		if v, ok := metrics.Get("records_rejected").(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := rejected()
+
+	// The accepted records must not be sent again or dead-lettered.
+	exporter := &otlpHTTPExporter{&httpSender{endpoint: server.URL + "/v1/logs"}}
+	if err := exporter.export([]LogMessage{{Message: "old"}, {Message: "new"}}); err != nil {
+		t.Errorf("export() error = %v; want nil for a partial success", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got %d", requests)
+	}
+	if got := rejected() - before; got != 1 {
+		t.Errorf("Expected 1 record counted as rejected, got %d", got)
+	}
+}
@@ -0,0 +1,222 @@
+package signoz
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	parserAuto   = "auto"
+	parserJSON   = "json"
+	parserLogfmt = "logfmt"
+	parserRegex  = "regex"
+	parserNone   = "none"
+
+	// parserLabel selects the parser for the logs of a container, overriding the parser option.
+	// parserPatternLabel sets the pattern of the regex parser.
+	parserLabel        = "signoz.parser"
+	parserPatternLabel = "signoz.parser.pattern"
+)
+
+// lineParser extracts the fields of a log line in one particular format, reading timestamps with
+// timestamps. parse returns false when the line is not in that format.
+type lineParser interface {
+	parse(data string, timestamps *timestampParser, logMessage *LogMessage) bool
+}
+
+// validParser reports whether name is a known parser.
+func validParser(name string) bool {
+	switch name {
+	case parserAuto, parserJSON, parserLogfmt, parserRegex, parserNone:
+		return true
+	}
+	_, exists := accessLogFormats[name]
+	return exists
+}
+
+// parserFor returns the parser for the logs of container: the one named by its signoz.parser
+// label, or the parser of the route. For the regex parser and the access log formats it also
+// returns the lineParser; the regex pattern comes from the signoz.parser.pattern label or the
+// parser.pattern option.
+func (a *Adapter) parserFor(container *docker.Container) (string, lineParser) {
+	labels := container.Config.Labels
+	name, exists := labels[parserLabel]
+	if !exists {
+		return a.parser, a.routeParser()
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !validParser(name) {
+		a.warn(container, "Unknown %s %q on container %s, using %s", parserLabel, name, container.Name, a.parser)
+		return a.parser, a.routeParser()
+	}
+	if format, exists := accessLogFormats[name]; exists {
+		return name, format
+	}
+	if name != parserRegex {
+		return name, nil
+	}
+
+	source, exists := labels[parserPatternLabel]
+	if !exists {
+		if a.pattern == nil {
+			a.warn(container, "Missing %s on container %s, using %s", parserPatternLabel, container.Name, a.parser)
+			return a.parser, a.routeParser()
+		}
+		return name, a.pattern
+	}
+	pattern, compiled := a.patterns[source]
+	if !compiled {
+		var err error
+		if pattern, err = newRegexParser(source); err != nil {
+			log.Printf("Invalid %s on container %s: %v", parserPatternLabel, container.Name, err)
+		}
+		store(a.patterns, source, pattern)
+	}
+	if pattern == nil {
+		return a.parser, a.routeParser()
+	}
+	return name, pattern
+}
+
+// routeParser returns the lineParser of the route's parser, if it has one.
+func (a *Adapter) routeParser() lineParser {
+	if format, exists := accessLogFormats[a.parser]; exists {
+		return format
+	}
+	if a.parser == parserRegex {
+		return a.pattern
+	}
+	return nil
+}
+
+// warn logs a problem with the configuration of container once.
+func (a *Adapter) warn(container *docker.Container, format string, v ...interface{}) {
+	if !a.warned[container.ID] {
+		store(a.warned, container.ID, true)
+		log.Printf(format, v...)
+	}
+}
+
+// maxStored bounds the maps the adapter keeps per container or label value. A full map is
+// emptied, which only costs compiling a pattern or logging a warning again.
+const maxStored = 1000
+
+// store sets key in m, emptying m first when it holds maxStored entries.
+func store[V any](m map[string]V, key string, value V) {
+	if len(m) >= maxStored {
+		clear(m)
+	}
+	m[key] = value
+}
+
+// parse fills logMessage from the log line data with the named parser. auto tries JSON, unless
+// DISABLE_JSON_PARSE is set, and then logfmt; json and logfmt only try the one format, regex and
+// the access log formats use p, and none leaves the line as it is. JSON keys are mapped with
+// fields and timestamps are read with timestamps. Lines that are not parsed fall back to
+// matching level names in the text.
+func (a *Adapter) parse(parser string, p lineParser, fields jsonFields, timestamps *timestampParser, data string, logMessage *LogMessage) {
+	switch parser {
+	case parserNone:
+		return
+	case parserJSON:
+		if a.parseJSONMessage(data, fields, timestamps, logMessage) {
+			return
+		}
+	case parserLogfmt:
+		if parseLogfmtMessage(data, timestamps, logMessage) {
+			return
+		}
+	case parserAuto:
+		if a.autoParseJson && a.parseJSONMessage(data, fields, timestamps, logMessage) {
+			return
+		}
+		if parseLogfmtMessage(data, timestamps, logMessage) {
+			return
+		}
+	default:
+		if p.parse(data, timestamps, logMessage) {
+			return
+		}
+	}
+	a.matchLogLevel(data, logMessage)
+}
+
+// parseJSONMessage maps the fields of a JSON log line onto logMessage, reading each field from
+// the keys fields lists for it. Keys other than the mapped ones become attributes. It returns
+// false when data is not JSON.
+func (a *Adapter) parseJSONMessage(data string, fields jsonFields, timestamps *timestampParser, logMessage *LogMessage) bool {
+	jsonInterface := parseJSON(data)
+	if jsonInterface == nil {
+		return false
+	}
+	jsonMap, ok := jsonInterface.(map[string]interface{})
+	if !ok {
+		return true
+	}
+	if fields == nil {
+		fields = defaultJSONFields
+	}
+
+	mapped := make(map[string]bool)
+	if value, key, found := fields.lookup(jsonMap, "timestamp"); found {
+		if text, ok := timestampText(value); ok {
+			if timestamp, ok := timestamps.parse(text); ok {
+				logMessage.Timestamp = timestamp.UnixNano()
+				mapped[key] = true
+			}
+		}
+	}
+	if value, key, found := fields.lookup(jsonMap, "level"); found {
+		if level, ok := levelText(value); ok {
+			logMessage.SeverityText = level
+			logMessage.SeverityNumber = severityNumber(level)
+			mapped[key] = true
+		}
+	}
+
+	stringFields := []struct {
+		field string
+		set   func(string)
+	}{
+		{"message", func(s string) { logMessage.Message = s }},
+		{"env", func(s string) { logMessage.Resources["deployment.environment"] = s }},
+		{"service", func(s string) { logMessage.Resources["service.name"] = s }},
+		{"namespace", func(s string) { logMessage.Resources["namespace"] = s }},
+		{"trace_id", func(s string) { logMessage.TraceID = s }},
+		{"span_id", func(s string) { logMessage.SpanID = s }},
+	}
+	for _, f := range stringFields {
+		if value, key, found := fields.lookup(jsonMap, f.field); found {
+			if s, ok := value.(string); ok {
+				f.set(s)
+				mapped[key] = true
+			}
+		}
+	}
+
+	// Get loop through non standard keys and save them as attributes inside logMessage
+	for key, value := range jsonMap {
+		if !mapped[key] {
+			logMessage.Attributes[key] = fmt.Sprintf("%v", value)
+		}
+	}
+	return true
+}
+
+// matchLogLevel takes the severity from the first level name found in data, unless
+// DISABLE_LOG_LEVEL_STRING_MATCH is set.
+func (a *Adapter) matchLogLevel(data string, logMessage *LogMessage) {
+	if !a.autoLogLevelStringMatch {
+		return
+	}
+	for level, number := range logLevelMap {
+		if strings.Contains(data, level) {
+			logMessage.SeverityText = strings.ToLower(level)
+			logMessage.SeverityNumber = number
+			break
+		}
+	}
+}
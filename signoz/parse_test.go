@@ -0,0 +1,103 @@
+package signoz
+
+import (
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+func newTestParseMessage(data string) LogMessage {
+	return LogMessage{
+		SeverityText:   "info",
+		SeverityNumber: logLevelMap["INFO"],
+		Attributes:     map[string]string{},
+		Resources:      map[string]string{},
+		Message:        data,
+	}
+}
+
+func TestParseModes(t *testing.T) {
+	const jsonLine = `{"level":"error","message":"failed","user":"42"}`
+	tests := []struct {
+		name         string
+		disableJSON  bool
+		parser       string
+		data         string
+		wantMessage  string
+		wantSeverity string
+	}{
+		{"Auto parses JSON", false, parserAuto, jsonLine, "failed", "error"},
+		{"Auto honors DISABLE_JSON_PARSE", true, parserAuto, jsonLine, jsonLine, "info"},
+		{"JSON overrides DISABLE_JSON_PARSE", true, parserJSON, jsonLine, "failed", "error"},
+		{"JSON falls back to level matching", false, parserJSON, "WARN disk almost full", "WARN disk almost full", "warn"},
+		{"None leaves the line alone", false, parserNone, jsonLine, jsonLine, "info"},
+		{"None skips level matching", false, parserNone, "ERROR failed", "ERROR failed", "info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.disableJSON {
+				t.Setenv("DISABLE_JSON_PARSE", "1")
+			}
+			a, err := newAdapter(&router.Route{})
+			if err != nil {
+				t.Fatalf("newAdapter() error = %v", err)
+			}
+
+			logMessage := newTestParseMessage(tt.data)
+			a.parse(tt.parser, nil, nil, nil, tt.data, &logMessage)
+			if logMessage.Message != tt.wantMessage || logMessage.SeverityText != tt.wantSeverity {
+				t.Errorf("parse() message = %q, severity = %q; want %q, %q", logMessage.Message, logMessage.SeverityText, tt.wantMessage, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestParserFor(t *testing.T) {
+	a, err := newAdapter(&router.Route{Options: map[string]string{"parser": "none"}})
+	if err != nil {
+		t.Fatalf("newAdapter() error = %v", err)
+	}
+
+	tests := []struct {
+		labels map[string]string
+		want   string
+	}{
+		{nil, parserNone},
+		{map[string]string{parserLabel: "json"}, parserJSON},
+		{map[string]string{parserLabel: " Auto "}, parserAuto},
+		{map[string]string{parserLabel: "nginx-combined"}, "nginx-combined"},
+		{map[string]string{parserLabel: "xml"}, parserNone},
+	}
+	for _, tt := range tests {
+		container := &docker.Container{ID: "abc", Config: &docker.Config{Labels: tt.labels}}
+		if got, _ := a.parserFor(container); got != tt.want {
+			t.Errorf("parserFor(%v) = %q; want %q", tt.labels, got, tt.want)
+		}
+	}
+
+	if _, err := newAdapter(&router.Route{Options: map[string]string{"parser": "xml"}}); err == nil {
+		t.Error("newAdapter() with an unknown parser: error = nil")
+	}
+}
+
+func TestWarnedIsBounded(t *testing.T) {
+	a, err := newAdapter(&router.Route{})
+	if err != nil {
+		t.Fatalf("newAdapter() error = %v", err)
+	}
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for i := 0; i <= maxStored; i++ {
+		a.warn(&docker.Container{ID: strconv.Itoa(i)}, "warning")
+	}
+	if len(a.warned) > maxStored {
+		t.Errorf("warned holds %d containers; want at most %d", len(a.warned), maxStored)
+	}
+}
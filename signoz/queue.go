@@ -0,0 +1,383 @@
+package signoz
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+const (
+	fsyncAlways   = "always"
+	fsyncInterval = "interval"
+	fsyncNever    = "never"
+
+	segmentExt       = ".wal"
+	cursorFile       = "cursor"
+	recordHeaderSize = 8
+	maxRecordSize    = 256 << 20
+)
+
+var errQueueClosed = errors.New("queue closed")
+
+// diskQueue is a write-ahead queue of batches kept in segment files. Batches are appended to
+// the newest segment and read back oldest first. A batch stays on disk until it is acknowledged,
+// so whatever was not delivered before a restart is replayed on startup. Each record is stored
+// as a 4 byte length, a 4 byte CRC32 and the JSON encoded batch.
+type diskQueue struct {
+	dir           string
+	segmentSize   int64
+	maxSize       int64
+	fsync         string
+	fsyncInterval time.Duration
+
+	mu         sync.Mutex
+	notify     chan struct{}
+	done       chan struct{}
+	segments   []int64
+	sizes      map[int64]int64
+	writer     *os.File
+	writeID    int64
+	lastSync   time.Time
+	readID     int64
+	readOffset int64
+	pendingID  int64
+	pendingLen int64
+}
+
+// newDiskQueue returns the disk queue configured for route, or nil when queue.dir is not set.
+// Every route gets its own subdirectory derived from its adapter and address, so several
+// routes can share the same queue.dir.
+func newDiskQueue(route *router.Route) (*diskQueue, error) {
+	baseDir := getopt(route, "queue.dir", "")
+	if baseDir == "" {
+		return nil, nil
+	}
+
+	q := &diskQueue{
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		sizes:  make(map[int64]int64),
+	}
+	var err error
+	if q.maxSize, err = getoptSize(route, "queue.max_size", 256<<20); err != nil {
+		return nil, err
+	}
+	if q.segmentSize, err = getoptSize(route, "queue.segment_size", 16<<20); err != nil {
+		return nil, err
+	}
+	if q.fsyncInterval, err = getoptDuration(route, "queue.fsync_interval", time.Second); err != nil {
+		return nil, err
+	}
+	q.fsync = getopt(route, "queue.fsync", fsyncAlways)
+	if q.fsync != fsyncAlways && q.fsync != fsyncInterval && q.fsync != fsyncNever {
+		return nil, fmt.Errorf("signoz: invalid queue.fsync %q", q.fsync)
+	}
+
+	q.dir = filepath.Join(baseDir, routeKey(route))
+
+	if err := q.open(); err != nil {
+		return nil, fmt.Errorf("signoz: cannot open queue in %s: %w", q.dir, err)
+	}
+	return q, nil
+}
+
+func (q *diskQueue) segmentPath(id int64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", id, segmentExt))
+}
+
+// open loads the existing segments and cursor and starts a fresh segment for writing, so a
+// segment torn by a crash is never appended to.
+func (q *diskQueue) open() error {
+	if err := os.MkdirAll(q.dir, 0o755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseInt(strings.TrimSuffix(entry.Name(), segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		q.segments = append(q.segments, id)
+		q.sizes[id] = info.Size()
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i] < q.segments[j] })
+
+	q.readID, q.readOffset = q.loadCursor()
+	for len(q.segments) > 0 && q.segments[0] < q.readID {
+		q.removeOldest()
+	}
+	if len(q.segments) == 0 || q.segments[0] != q.readID {
+		q.readOffset = 0
+	}
+
+	q.writeID = 1
+	if len(q.segments) > 0 {
+		q.writeID = q.segments[len(q.segments)-1] + 1
+	}
+	if err := q.createSegment(); err != nil {
+		return err
+	}
+	if q.segments[0] != q.readID {
+		q.readID = q.segments[0]
+	}
+
+	if pending := q.diskSize(); pending > 0 {
+		log.Printf("Replaying %d bytes of queued logs from %s", pending, q.dir)
+	}
+	return nil
+}
+
+func (q *diskQueue) createSegment() error {
+	writer, err := os.OpenFile(q.segmentPath(q.writeID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	q.writer = writer
+	q.segments = append(q.segments, q.writeID)
+	q.sizes[q.writeID] = 0
+	return nil
+}
+
+func (q *diskQueue) loadCursor() (int64, int64) {
+	data, err := os.ReadFile(filepath.Join(q.dir, cursorFile))
+	if err != nil {
+		return 0, 0
+	}
+	var id, offset int64
+	if _, err := fmt.Sscanf(string(data), "%d %d", &id, &offset); err != nil {
+		return 0, 0
+	}
+	return id, offset
+}
+
+func (q *diskQueue) saveCursor() error {
+	tmp := filepath.Join(q.dir, cursorFile+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(f, "%d %d", q.readID, q.readOffset)
+	if q.fsync == fsyncAlways {
+		f.Sync()
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(q.dir, cursorFile))
+}
+
+func (q *diskQueue) diskSize() int64 {
+	var total int64
+	for _, size := range q.sizes {
+		total += size
+	}
+	return total
+}
+
+// removeOldest deletes the oldest segment. If the reader was still in it, reading continues
+// at the start of the next segment.
+func (q *diskQueue) removeOldest() {
+	id := q.segments[0]
+	os.Remove(q.segmentPath(id))
+	delete(q.sizes, id)
+	q.segments = q.segments[1:]
+	if q.readID == id && len(q.segments) > 0 {
+		q.readID, q.readOffset = q.segments[0], 0
+	}
+}
+
+// push appends a batch to the queue and wakes up the reader.
+func (q *diskQueue) push(logs []LogMessage) error {
+	payload, err := json.Marshal(logs)
+	if err != nil {
+		return err
+	}
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.writer == nil {
+		return errQueueClosed
+	}
+
+	if q.sizes[q.writeID] > 0 && q.sizes[q.writeID]+int64(len(record)) > q.segmentSize {
+		q.writer.Sync()
+		q.writer.Close()
+		q.writeID++
+		if err := q.createSegment(); err != nil {
+			q.writer = nil
+			return err
+		}
+	}
+
+	n, err := q.writer.Write(record)
+	q.sizes[q.writeID] += int64(n)
+	if err != nil {
+		return err
+	}
+	if q.fsync == fsyncAlways || (q.fsync == fsyncInterval && time.Since(q.lastSync) >= q.fsyncInterval) {
+		if err := q.writer.Sync(); err != nil {
+			return err
+		}
+		q.lastSync = time.Now()
+	}
+
+	q.evict()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// evict removes the oldest segments until the queue fits into maxSize again. The segment
+// being written is never evicted.
+func (q *diskQueue) evict() {
+	for q.maxSize > 0 && q.diskSize() > q.maxSize && len(q.segments) > 1 {
+		id, size := q.segments[0], q.sizes[q.segments[0]]
+		q.removeOldest()
+		q.saveCursor()
+		log.Printf("Disk queue %s is full, dropped oldest segment %d (%d bytes)", q.dir, id, size)
+	}
+}
+
+// peek blocks until a batch is available and returns the oldest unacknowledged one. The same
+// batch is returned again until ack is called.
+func (q *diskQueue) peek() ([]LogMessage, error) {
+	for {
+		q.mu.Lock()
+		logs, err := q.next()
+		q.mu.Unlock()
+		if err != nil || logs != nil {
+			return logs, err
+		}
+
+		select {
+		case <-q.notify:
+		case <-q.done:
+			return nil, errQueueClosed
+		}
+	}
+}
+
+func (q *diskQueue) next() ([]LogMessage, error) {
+	for {
+		if q.writer == nil {
+			return nil, errQueueClosed
+		}
+		if q.readID == q.writeID && q.readOffset >= q.sizes[q.writeID] {
+			return nil, nil
+		}
+
+		payload, err := readRecord(q.segmentPath(q.readID), q.readOffset)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Skipping rest of queue segment %d: %v", q.readID, err)
+			}
+			if q.readID == q.writeID {
+				q.readOffset = q.sizes[q.writeID]
+				return nil, nil
+			}
+			q.removeOldest()
+			q.saveCursor()
+			continue
+		}
+
+		var logs []LogMessage
+		if err := json.Unmarshal(payload, &logs); err != nil {
+			log.Printf("Skipping unreadable batch in queue segment %d: %v", q.readID, err)
+			q.readOffset += recordHeaderSize + int64(len(payload))
+			continue
+		}
+		q.pendingID, q.pendingLen = q.readID, recordHeaderSize+int64(len(payload))
+		return logs, nil
+	}
+}
+
+// ack removes the batch returned by the last peek from the queue.
+func (q *diskQueue) ack() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pendingID != q.readID || q.pendingLen == 0 {
+		// The segment was evicted while the batch was being sent.
+		return nil
+	}
+	q.readOffset += q.pendingLen
+	q.pendingLen = 0
+	if q.readID != q.writeID && q.readOffset >= q.sizes[q.readID] {
+		q.removeOldest()
+	}
+	return q.saveCursor()
+}
+
+// close stops the queue. Unacknowledged batches stay on disk for the next start.
+func (q *diskQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.writer == nil {
+		return nil
+	}
+	close(q.done)
+	q.writer.Sync()
+	err := q.writer.Close()
+	q.writer = nil
+	return err
+}
+
+func readRecord(path string, offset int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("truncated record header at offset %d", offset)
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecordSize {
+		return nil, fmt.Errorf("invalid record length %d at offset %d", length, offset)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(f, payload); err != nil {
+		return nil, fmt.Errorf("truncated record at offset %d", offset)
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, fmt.Errorf("checksum mismatch at offset %d", offset)
+	}
+	return payload, nil
+}
@@ -0,0 +1,127 @@
+package signoz
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestDiskQueueDisabled(t *testing.T) {
+	q, err := newDiskQueue(&router.Route{})
+	if err != nil || q != nil {
+		t.Errorf("newDiskQueue() = %v, %v; want nil, nil", q, err)
+	}
+}
+
+func TestDiskQueuePushPeekAck(t *testing.T) {
+	q, err := newDiskQueue(&router.Route{Adapter: "signoz", Address: "collector:8082", Options: map[string]string{"queue.dir": t.TempDir()}})
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer q.close()
+
+	for _, msg := range []string{"first", "second"} {
+		if err := q.push([]LogMessage{{Message: msg}}); err != nil {
+			t.Fatalf("push() error = %v", err)
+		}
+	}
+
+	logs, err := q.peek()
+	if err != nil || logs[0].Message != "first" {
+		t.Fatalf("peek() = %v, %v; want first", logs, err)
+	}
+	logs, _ = q.peek()
+	if logs[0].Message != "first" {
+		t.Errorf("peek() without ack = %s; want first", logs[0].Message)
+	}
+	q.ack()
+	logs, _ = q.peek()
+	if logs[0].Message != "second" {
+		t.Errorf("peek() after ack = %s; want second", logs[0].Message)
+	}
+}
+
+func TestDiskQueueReplay(t *testing.T) {
+	dir := t.TempDir()
+	q, err := newDiskQueue(&router.Route{Adapter: "signoz", Address: "collector:8082", Options: map[string]string{"queue.dir": dir, "queue.segment_size": "100"}})
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	for _, msg := range []string{"first", "second", "third"} {
+		q.push([]LogMessage{{Message: msg}})
+	}
+	q.peek()
+	q.ack()
+	q.close()
+
+	q, err = newDiskQueue(&router.Route{Adapter: "signoz", Address: "collector:8082", Options: map[string]string{"queue.dir": dir, "queue.segment_size": "100"}})
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer q.close()
+	for _, want := range []string{"second", "third"} {
+		logs, err := q.peek()
+		if err != nil || logs[0].Message != want {
+			t.Fatalf("peek() after restart = %v, %v; want %s", logs, err, want)
+		}
+		q.ack()
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(q.dir, "*"+segmentExt))
+	if len(segments) != 1 {
+		t.Errorf("Expected acknowledged segments to be removed, found %d segments", len(segments))
+	}
+}
+
+func TestDiskQueueEvictsOldest(t *testing.T) {
+	q, err := newDiskQueue(&router.Route{Adapter: "signoz", Address: "collector:8082", Options: map[string]string{"queue.dir": t.TempDir(), "queue.segment_size": "100", "queue.max_size": "250"}})
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer q.close()
+
+	for _, msg := range []string{"first", "second", "third", "fourth", "fifth"} {
+		q.push([]LogMessage{{Message: msg}})
+	}
+	if size := q.diskSize(); size > 250 {
+		t.Errorf("diskSize() = %d; want <= 250", size)
+	}
+	logs, _ := q.peek()
+	if logs[0].Message == "first" {
+		t.Error("Expected oldest batch to be evicted")
+	}
+}
+
+func TestDiskQueueSkipsCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	q, err := newDiskQueue(&router.Route{Adapter: "signoz", Address: "collector:8082", Options: map[string]string{"queue.dir": dir}})
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	q.push([]LogMessage{{Message: "first"}})
+	path := q.segmentPath(q.writeID)
+	q.close()
+
+	// Simulate a write torn by a crash.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.Write([]byte{0, 0, 1})
+	f.Close()
+
+	q, err = newDiskQueue(&router.Route{Adapter: "signoz", Address: "collector:8082", Options: map[string]string{"queue.dir": dir}})
+	if err != nil {
+		t.Fatalf("newDiskQueue() error = %v", err)
+	}
+	defer q.close()
+	logs, _ := q.peek()
+	if logs[0].Message != "first" {
+		t.Fatalf("peek() = %v; want first", logs)
+	}
+	q.ack()
+	q.push([]LogMessage{{Message: "second"}})
+	logs, _ = q.peek()
+	if logs[0].Message != "second" {
+		t.Errorf("peek() = %v; want second", logs)
+	}
+}
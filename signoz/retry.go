@@ -0,0 +1,121 @@
+package signoz
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+// sleep waits for d or until stop is closed, and reports whether it waited the full time. It
+// is replaced in tests to avoid waiting for real backoff intervals.
+var sleep = func(d time.Duration, stop <-chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// retryConfig controls how failed batches are retried. The interval between attempts grows
+// exponentially from initialInterval up to maxInterval and is randomized by jitter, a fraction
+// of the interval. Retrying stops after maxAttempts attempts or once maxElapsedTime has passed;
+// zero disables the respective limit.
+type retryConfig struct {
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	multiplier      float64
+	jitter          float64
+	maxElapsedTime  time.Duration
+	maxAttempts     int
+}
+
+func newRetryConfig(route *router.Route) (retryConfig, error) {
+	var c retryConfig
+	var err error
+	if c.initialInterval, err = getoptDuration(route, "retry.initial_interval", time.Second); err != nil {
+		return c, err
+	}
+	if c.maxInterval, err = getoptDuration(route, "retry.max_interval", 30*time.Second); err != nil {
+		return c, err
+	}
+	if c.multiplier, err = getoptFloat(route, "retry.multiplier", 2); err != nil {
+		return c, err
+	}
+	if c.jitter, err = getoptFloat(route, "retry.jitter", 0.5); err != nil {
+		return c, err
+	}
+	if c.maxElapsedTime, err = getoptDuration(route, "retry.max_elapsed_time", 5*time.Minute); err != nil {
+		return c, err
+	}
+	if c.maxAttempts, err = getoptInt(route, "retry.max_attempts", 10); err != nil {
+		return c, err
+	}
+	if c.jitter < 0 || c.jitter > 1 {
+		return c, fmt.Errorf("signoz: retry.jitter must be between 0 and 1, got %v", c.jitter)
+	}
+	if c.multiplier < 1 {
+		return c, fmt.Errorf("signoz: retry.multiplier must be at least 1, got %v", c.multiplier)
+	}
+	return c, nil
+}
+
+// backoff returns the wait before the attempt following the given one.
+func (c retryConfig) backoff(attempt int) time.Duration {
+	interval := float64(c.initialInterval) * math.Pow(c.multiplier, float64(attempt-1))
+	if interval > float64(c.maxInterval) {
+		interval = float64(c.maxInterval)
+	}
+	delta := c.jitter * interval
+	return time.Duration(interval - delta + rand.Float64()*(2*delta))
+}
+
+// retryAfter returns the wait requested by the collector along with err, if any.
+func retryAfter(err error) time.Duration {
+	var exportErr *exportError
+	if errors.As(err, &exportErr) {
+		return exportErr.retryAfter
+	}
+	return 0
+}
+
+// isRetryable reports whether err is a transient export failure.
+func isRetryable(err error) bool {
+	var exportErr *exportError
+	return errors.As(err, &exportErr) && exportErr.retryable
+}
+
+// do calls send until it succeeds, fails permanently or the retry limits are reached. When the
+// collector asks for a pause, e.g. with Retry-After on 429, that pause is used instead of the
+// backoff. It returns right away when the circuit breaker is open, and stops retrying once stop
+// is closed.
+func (c retryConfig) do(stop <-chan struct{}, send func() error) error {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := send()
+		if err == nil || !isRetryable(err) || errors.Is(err, errCircuitOpen) {
+			return err
+		}
+		if c.maxAttempts > 0 && attempt >= c.maxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		wait := c.backoff(attempt)
+		if pause := retryAfter(err); pause > 0 {
+			wait = pause
+		}
+		if c.maxElapsedTime > 0 && time.Since(start)+wait > c.maxElapsedTime {
+			return fmt.Errorf("giving up after %s: %w", time.Since(start).Round(time.Millisecond), err)
+		}
+		log.Printf("Error sending logs (attempt %d), retrying in %s: %v", attempt, wait.Round(time.Millisecond), err)
+		if !sleep(wait, stop) {
+			return fmt.Errorf("giving up on shutdown after %d attempts: %w", attempt, err)
+		}
+	}
+}
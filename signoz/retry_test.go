@@ -0,0 +1,249 @@
+package signoz
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func stubSleep(t *testing.T) *[]time.Duration {
+	var waits []time.Duration
+	original := sleep
+	sleep = func(d time.Duration, stop <-chan struct{}) bool {
+		waits = append(waits, d)
+		return true
+	}
+	t.Cleanup(func() { sleep = original })
+	return &waits
+}
+
+func TestRetryBackoff(t *testing.T) {
+	c := retryConfig{initialInterval: time.Second, maxInterval: 5 * time.Second, multiplier: 2}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := c.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s; want %s", i+1, got, w)
+		}
+	}
+
+	c.jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := c.backoff(2); got < time.Second || got > 3*time.Second {
+			t.Fatalf("backoff(2) with jitter = %s; want between 1s and 3s", got)
+		}
+	}
+}
+
+func TestRetryDo(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error
+		maxAttempts  int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"Succeeds first time", []error{nil}, 5, 1, false},
+		{"Retries retryable errors", []error{&exportError{err: errors.New("503"), retryable: true}, &exportError{err: errors.New("503"), retryable: true}, nil}, 5, 3, false},
+		{"Stops on permanent error", []error{&exportError{err: errors.New("400")}}, 5, 1, true},
+		{"Stops on plain error", []error{errors.New("marshal failed")}, 5, 1, true},
+		{"Gives up after max attempts", []error{&exportError{err: errors.New("503"), retryable: true}}, 3, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waits := stubSleep(t)
+			c := retryConfig{initialInterval: time.Second, maxInterval: time.Minute, multiplier: 2, maxAttempts: tt.maxAttempts}
+
+			attempts := 0
+			err := c.do(nil, func() error {
+				err := tt.errs[min(attempts, len(tt.errs)-1)]
+				attempts++
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("do() attempts = %d; want %d", attempts, tt.wantAttempts)
+			}
+			if len(*waits) != tt.wantAttempts-1 {
+				t.Errorf("do() waited %d times; want %d", len(*waits), tt.wantAttempts-1)
+			}
+		})
+	}
+}
+
+func TestRetryMaxElapsedTime(t *testing.T) {
+	stubSleep(t)
+	c := retryConfig{initialInterval: time.Minute, maxInterval: time.Minute, multiplier: 2, maxElapsedTime: 30 * time.Second}
+
+	attempts := 0
+	err := c.do(nil, func() error {
+		attempts++
+		return &exportError{err: errors.New("503"), retryable: true}
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("do() = %v after %d attempts; want error after 1 attempt", err, attempts)
+	}
+}
+
+func TestExportStatusClassification(t *testing.T) {
+	tests := []struct {
+		status    int
+		retryable bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusForbidden, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			exporter := &jsonExporter{&httpSender{endpoint: server.URL}}
+			err := exporter.export([]LogMessage{{Message: "hello"}})
+			if err == nil {
+				t.Fatal("export() error = nil; want error")
+			}
+			if isRetryable(err) != tt.retryable {
+				t.Errorf("isRetryable() = %v; want %v", isRetryable(err), tt.retryable)
+			}
+		})
+	}
+
+	// Connection refused
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	exporter := &jsonExporter{&httpSender{endpoint: server.URL}}
+	if err := exporter.export(nil); !isRetryable(err) {
+		t.Errorf("isRetryable(%v) = false; want true", err)
+	}
+}
+
+func TestNewRetryConfig(t *testing.T) {
+	route := &router.Route{Options: map[string]string{
+		"retry.initial_interval": "200ms",
+		"retry.max_attempts":     "3",
+	}}
+	c, err := newRetryConfig(route)
+	if err != nil {
+		t.Fatalf("newRetryConfig() error = %v", err)
+	}
+	if c.initialInterval != 200*time.Millisecond || c.maxAttempts != 3 || c.maxInterval != 30*time.Second {
+		t.Errorf("newRetryConfig() = %+v", c)
+	}
+
+	route.Options["retry.jitter"] = "2"
+	if _, err := newRetryConfig(route); err == nil {
+		t.Error("newRetryConfig() with jitter 2 error = nil; want error")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s; want %s", tt.value, got, tt.want)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got < 50*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %s; want about 1m", future, got)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	waits := stubSleep(t)
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "42")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	exporter := &jsonExporter{&httpSender{endpoint: server.URL}}
+	c := retryConfig{initialInterval: time.Second, maxInterval: time.Minute, multiplier: 2, maxAttempts: 3}
+	if err := c.do(nil, func() error { return exporter.export(nil) }); err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 42*time.Second {
+		t.Errorf("waits = %v; want [42s]", *waits)
+	}
+}
+
+func TestSendSplitsOnPayloadTooLarge(t *testing.T) {
+	var received []LogMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		tooLarge := len(logs) > 2
+		for _, logMessage := range logs {
+			tooLarge = tooLarge || len(logMessage.Message) > 100
+		}
+		if tooLarge {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		received = append(received, logs...)
+	}))
+	defer server.Close()
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	logAdapter, err := NewSignozAdapter(&router.Route{Options: map[string]string{"batch.max_record_bytes": "50"}})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+	adapter := logAdapter.(*Adapter)
+
+	logs := []LogMessage{{Message: "1"}, {Message: "2"}, {Message: "3"}, {Message: strings.Repeat("x", 200)}, {Message: "5"}}
+	if err := adapter.send(logs, true); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	if len(received) != len(logs) {
+		t.Fatalf("Collector received %d logs; want %d", len(received), len(logs))
+	}
+	for _, logMessage := range received {
+		if len(logMessage.Message) > 50 {
+			t.Errorf("Expected oversized message to be truncated, got %d bytes", len(logMessage.Message))
+		}
+	}
+}
+
+func TestTruncateRecord(t *testing.T) {
+	m := LogMessage{Message: "héllo wörld, this is long"}
+	truncated, ok := truncateRecord(m, 20)
+	if !ok || len(truncated.Message) > 20 || !utf8.ValidString(truncated.Message) || !strings.HasSuffix(truncated.Message, "...[truncated]") {
+		t.Errorf("truncateRecord() = %q, %v", truncated.Message, ok)
+	}
+	if _, ok := truncateRecord(LogMessage{Message: "short"}, 20); ok {
+		t.Error("truncateRecord() truncated a short message")
+	}
+}
@@ -0,0 +1,128 @@
+package signoz
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// adapters holds the adapters whose Stream is running, so a SIGTERM or SIGINT can flush all
+// of them before logspout exits.
+var adapters = struct {
+	sync.Mutex
+	running map[*Adapter]struct{}
+	once    sync.Once
+}{running: make(map[*Adapter]struct{})}
+
+func registerAdapter(a *Adapter) {
+	adapters.once.Do(func() { go handleSignals() })
+	adapters.Lock()
+	adapters.running[a] = struct{}{}
+	adapters.Unlock()
+}
+
+func unregisterAdapter(a *Adapter) {
+	adapters.Lock()
+	delete(adapters.running, a)
+	adapters.Unlock()
+}
+
+// handleSignals waits for SIGTERM or SIGINT, stops all running adapters in parallel and then
+// raises the signal again with the default handler restored, so logspout exits as it would have.
+func handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-signals
+	log.Printf("Received %s, flushing buffered logs", sig)
+
+	adapters.Lock()
+	var wg sync.WaitGroup
+	for a := range adapters.running {
+		wg.Add(1)
+		go func(a *Adapter) {
+			defer wg.Done()
+			a.stop()
+		}(a)
+	}
+	adapters.Unlock()
+	wg.Wait()
+
+	signal.Stop(signals)
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		p.Signal(sig)
+	}
+}
+
+// stop makes Stream flush what is buffered and waits until that is done. It is safe to call
+// more than once.
+func (a *Adapter) stop() {
+	a.stopOnce.Do(func() { close(a.stopping) })
+	<-a.stopped
+}
+
+// finalFlush waits for the export workers and sends the records they hold and the remaining
+// buffered records. Then it waits for the intake to stop, which sends the events still held by
+// the multiline aggregator, and sends the records buffered meanwhile. It gives up after
+// shutdownTimeout and logs how many records were flushed or lost, counting the ones discarded
+// since the intake stopped. Once the adapter is stopping failed batches are not retried.
+func (a *Adapter) finalFlush() {
+	a.buffer.drain()
+	pending, dropped := a.buffer.take()
+	if dropped > 0 {
+		log.Printf("Buffer full, dropped %d log records (policy %s)", dropped, a.buffer.policy)
+	}
+
+	// flushed counts the records sent here and late the ones taken from the buffer after the
+	// intake stopped, so the records still with the workers, which include those they hold,
+	// and the ones not sent yet are known on timeout.
+	var flushed, late atomic.Int64
+	type outcome struct{ flushed, total int }
+	result := make(chan outcome, 1)
+	go func() {
+		send := func(records []LogMessage) {
+			for _, batch := range a.batch.split(records) {
+				if err := a.flush(batch); err != nil {
+					if errors.Is(err, errCircuitOpen) {
+						a.deadLetters.write(batch, err)
+					}
+					log.Println("Error sending logs:", err)
+					continue
+				}
+				flushed.Add(int64(len(batch)))
+			}
+		}
+
+		// Let the workers finish first, so records of a container are not sent out of order.
+		// Whatever they held back while the circuit breaker was open goes first.
+		remaining := append(a.pool.close(), pending...)
+		send(remaining)
+
+		<-a.intakeDone
+		logs, _ := a.buffer.take()
+		late.Store(int64(len(logs)))
+		send(logs)
+		result <- outcome{int(flushed.Load()), len(remaining) + len(logs)}
+	}()
+
+	select {
+	case r := <-result:
+		lost := r.total - r.flushed + int(a.discarded.Load())
+		if lost == 0 {
+			log.Printf("Flushed %d log records on shutdown", r.flushed)
+		} else {
+			log.Printf("Flushed %d log records on shutdown, %d lost", r.flushed, lost)
+		}
+	case <-time.After(a.shutdownTimeout):
+		lost := a.pool.inFlight.Load() + int64(len(pending)) + late.Load() + int64(a.buffer.len()) - flushed.Load() + a.discarded.Load()
+		log.Printf("Shutdown flush timed out after %s, up to %d log records lost", a.shutdownTimeout, lost)
+	}
+
+	if a.queue != nil {
+		a.queue.close()
+	}
+}
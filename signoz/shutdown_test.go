@@ -0,0 +1,267 @@
+package signoz
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+func newTestMessage(data string) *router.Message {
+	return &router.Message{
+		Container: &docker.Container{Config: &docker.Config{Image: "serviceImage"}},
+		Data:      data,
+		Time:      time.Now(),
+	}
+}
+
+func TestStreamFlushesOnClose(t *testing.T) {
+	received := make(chan int, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		received <- len(logs)
+	}))
+	defer server.Close()
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	adapter, err := NewSignozAdapter(&router.Route{Options: map[string]string{"batch.timeout": "1h"}})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+
+	logStream := make(chan *router.Message, 3)
+	logStream <- newTestMessage("first")
+	logStream <- newTestMessage("second")
+	logStream <- newTestMessage("third")
+	close(logStream)
+
+	done := make(chan struct{})
+	go func() {
+		adapter.Stream(logStream)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stream() did not return after the log stream was closed")
+	}
+	select {
+	case n := <-received:
+		if n != 3 {
+			t.Errorf("Expected final flush of 3 logs, got %d", n)
+		}
+	default:
+		t.Fatal("Buffered logs were not flushed when the stream closed")
+	}
+}
+
+func TestFinalFlushTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	adapter, err := NewSignozAdapter(&router.Route{Options: map[string]string{"batch.timeout": "1h", "shutdown.timeout": "100ms"}})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+
+	logStream := make(chan *router.Message, 1)
+	logStream <- newTestMessage("stuck")
+	close(logStream)
+
+	start := time.Now()
+	adapter.Stream(logStream)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Stream() took %s to return; want about shutdown.timeout", elapsed)
+	}
+}
+
+func TestAdapterStopWhileStreaming(t *testing.T) {
+	received := make(chan int, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		received <- len(logs)
+	}))
+	defer server.Close()
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	logAdapter, err := NewSignozAdapter(&router.Route{Options: map[string]string{"batch.timeout": "1h"}})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+	adapter := logAdapter.(*Adapter)
+
+	logStream := make(chan *router.Message)
+	go adapter.Stream(logStream)
+	logStream <- newTestMessage("first")
+	logStream <- newTestMessage("second")
+	// Messages pass through the multiline stage first, so wait until one is buffered.
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		adapter.buffer.mu.Lock()
+		buffered := len(adapter.buffer.records)
+		adapter.buffer.mu.Unlock()
+		if buffered > 0 {
+			break
+		}
+	}
+
+	// Simulates what the signal handler does for every running adapter.
+	adapter.stop()
+	select {
+	case n := <-received:
+		if n < 1 {
+			t.Errorf("Expected buffered logs on stop, got %d", n)
+		}
+	default:
+		t.Fatal("Buffered logs were not flushed on stop")
+	}
+	close(logStream)
+}
+
+func TestShutdownInterruptsRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	adapter, err := NewSignozAdapter(&router.Route{Options: map[string]string{"batch.timeout": "10ms", "shutdown.timeout": "200ms"}})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+
+	logStream := make(chan *router.Message)
+	done := make(chan struct{})
+	go func() {
+		adapter.Stream(logStream)
+		close(done)
+	}()
+	logStream <- newTestMessage("first")
+	// Let the first batch go out and fail, so its worker is waiting to retry.
+	time.Sleep(100 * time.Millisecond)
+	logStream <- newTestMessage("second")
+	close(logStream)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stream() did not return within the shutdown timeout while retrying")
+	}
+}
+
+func TestShutdownTimeoutCountsInFlightRecords(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	adapter, err := NewSignozAdapter(&router.Route{Options: map[string]string{"batch.timeout": "10ms", "shutdown.timeout": "100ms"}})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+
+	logStream := make(chan *router.Message, 3)
+	done := make(chan struct{})
+	go func() {
+		adapter.Stream(logStream)
+		close(done)
+	}()
+	logStream <- newTestMessage("first")
+	logStream <- newTestMessage("second")
+	<-requested
+	logStream <- newTestMessage("third")
+	close(logStream)
+	<-done
+
+	// The timed out flush still logs after the adapter stopped.
+	log.SetOutput(os.Stderr)
+	if !strings.Contains(output.String(), "up to 3 log records lost") {
+		t.Errorf("log output = %q; want 3 records reported lost", output.String())
+	}
+}
+
+func TestStopFlushesMultilineEventsAndCountsLateRecords(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	var received []LogMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		received = append(received, logs...)
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-unblock
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	logAdapter, err := NewSignozAdapter(&router.Route{Options: map[string]string{"batch.timeout": "1h", "multiline": "java"}})
+	if err != nil {
+		t.Fatalf("NewSignozAdapter() error = %v", err)
+	}
+	adapter := logAdapter.(*Adapter)
+
+	logStream := make(chan *router.Message)
+	go adapter.Stream(logStream)
+	// The event is held by the multiline aggregator until the stop.
+	logStream <- newTestMessage("java.lang.IllegalStateException: boom")
+	logStream <- newTestMessage("\tat com.example.App.run(App.java:10)")
+
+	stopped := make(chan struct{})
+	go func() {
+		adapter.stop()
+		close(stopped)
+	}()
+	<-requested
+	// Logged while the final flush runs: logspout must not block, and the line is lost.
+	select {
+	case logStream <- newTestMessage("late"):
+	case <-time.After(time.Second):
+		t.Fatal("Sending to the log stream blocked after the stop")
+	}
+	close(unblock)
+	<-stopped
+	close(logStream)
+
+	if len(received) != 1 || !strings.Contains(received[0].Message, "App.java:10") {
+		t.Errorf("received %v; want the joined event", received)
+	}
+	// Flushes timed out in other tests may still log.
+	log.SetOutput(os.Stderr)
+	if !strings.Contains(output.String(), "Flushed 1 log records on shutdown, 1 lost") {
+		t.Errorf("log output = %q; want 1 record flushed and 1 lost", output.String())
+	}
+}
@@ -1,15 +1,21 @@
 package signoz
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gliderlabs/logspout/router"
 )
@@ -24,8 +30,6 @@ var logLevelMap = map[string]int{
 	"FATAL":   21,
 }
 
-var standardJsonAttributeKeys = []string{"timestamp", "level", "message", "service", "namespace", "env", "environment"}
-
 func contains(slice []string, item string) bool {
 	for _, v := range slice {
 		if v == item {
@@ -35,6 +39,22 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// parseSeverity reads a minimum severity given as a level name such as ERROR or as an OTLP
+// severity number. An empty value lets everything through.
+func parseSeverity(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if number, exists := logLevelMap[strings.ToUpper(value)]; exists {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("signoz: invalid min_severity %q", value)
+	}
+	return number, nil
+}
+
 func init() {
 	router.AdapterFactories.Register(NewSignozAdapter, "signoz")
 }
@@ -52,14 +72,119 @@ func init() {
 
 func parseJSON(s string) interface{} {
 	var result interface{} // This can hold any valid JSON structure
-	if err := json.Unmarshal([]byte(s), &result); err != nil {
+	// Numbers are kept as json.Number, so epoch timestamps in nanoseconds don't lose precision
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
 		return nil // If JSON is invalid, return nil
 	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil // Trailing data after the JSON value
+	}
 	return result // Return the parsed JSON
 }
 
-// NewSignozAdapter returns a configured signoz.Adapter
+const (
+	protocolJSON     = "json"
+	protocolOTLPHTTP = "otlp"
+	protocolOTLPGRPC = "grpc"
+)
+
+// endpointsFromRoute returns the export protocol and collector URLs for a route. The adapter
+// transports select both: signoz:// posts SigNoz JSON over http, "+https" switches to https,
+// "+otlp" sends OTLP protobuf to /v1/logs and "+grpc" calls the OTLP LogsService over gRPC,
+// e.g. signoz+otlp+https:// or signoz+grpc://. logspout keeps only the host of the route URI,
+// so the path, optionally with a query, comes from the path option and applies to every
+// address that has none of its own. The endpoints option adds more addresses for load balancing
+// and failover. SIGNOZ_LOG_ENDPOINT, a list of URLs, is only used when the route has no address.
+func endpointsFromRoute(route *router.Route) (string, []string, error) {
+	protocol, scheme, err := transportsFromRoute(route)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var addresses []string
+	if route.Address != "" {
+		addresses = append(addresses, scheme+"://"+route.Address)
+	}
+	for _, address := range splitList(route.Options["endpoints"]) {
+		addresses = append(addresses, scheme+"://"+address)
+	}
+	if len(addresses) == 0 {
+		addresses = splitList(os.Getenv("SIGNOZ_LOG_ENDPOINT"))
+	}
+	if len(addresses) == 0 {
+		addresses = []string{"http://localhost:8082"}
+	}
+	path, err := url.Parse(getopt(route, "path", ""))
+	if err != nil {
+		return "", nil, fmt.Errorf("signoz: invalid path: %w", err)
+	}
+
+	endpoints := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		endpoint, err := url.Parse(address)
+		if err != nil {
+			return "", nil, fmt.Errorf("signoz: invalid address %q: %w", address, err)
+		}
+		if endpoint.Host == "" {
+			return "", nil, fmt.Errorf("signoz: missing host in address %q", address)
+		}
+		if endpoint.Path == "" && endpoint.RawQuery == "" {
+			endpoint.Path, endpoint.RawQuery = path.Path, path.RawQuery
+		}
+		if protocol == protocolOTLPHTTP && endpoint.Path == "" {
+			endpoint.Path = "/v1/logs"
+		}
+		endpoints = append(endpoints, endpoint.String())
+	}
+	return protocol, endpoints, nil
+}
+
+// transportsFromRoute returns the export protocol and URL scheme selected by the transports of
+// the route adapter.
+func transportsFromRoute(route *router.Route) (string, string, error) {
+	protocol, scheme := protocolJSON, "http"
+	for _, transport := range strings.Split(route.Adapter, "+")[1:] {
+		switch transport {
+		case "http", "https":
+			scheme = transport
+		case protocolOTLPHTTP, protocolOTLPGRPC:
+			protocol = transport
+		default:
+			return "", "", fmt.Errorf("signoz: unsupported transport %q", transport)
+		}
+	}
+	return protocol, scheme, nil
+}
+
+// splitList splits a list option. Items are separated by commas or, inside route URIs where
+// logspout itself splits on commas, by "|".
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '|' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// NewSignozAdapter returns a configured signoz.Adapter, or an adapter fanning out to several
+// of them when the route declares destinations.
 func NewSignozAdapter(route *router.Route) (router.LogAdapter, error) {
+	if route.Options["destinations"] != "" {
+		return newFanout(route)
+	}
+	return newAdapter(route)
+}
+
+func newAdapter(route *router.Route) (*Adapter, error) {
+	exp, err := newExporter(route)
+	if err != nil {
+		return nil, err
+	}
+
 	autoParseJson := true
 	if _, exists := os.LookupEnv("DISABLE_JSON_PARSE"); exists {
 		autoParseJson = false
@@ -75,6 +200,79 @@ func NewSignozAdapter(route *router.Route) (router.LogAdapter, error) {
 		envValue = ""
 	}
 
+	retry, err := newRetryConfig(route)
+	if err != nil {
+		return nil, err
+	}
+
+	queue, err := newDiskQueue(route)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := newLogBuffer(route)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := newBatchConfig(route)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := newDeadLetterSink(route)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRecordBytes, err := getoptSize(route, "batch.max_record_bytes", 64<<10)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTimeout, err := getoptDuration(route, "shutdown.timeout", 8*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	workers, err := getoptInt(route, "export.workers", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := strings.ToLower(getopt(route, "parser", parserAuto))
+	if !validParser(parser) {
+		return nil, fmt.Errorf("signoz: invalid parser %q", parser)
+	}
+
+	var pattern *regexParser
+	if source := getopt(route, "parser.pattern", ""); source != "" {
+		if pattern, err = newRegexParser(source); err != nil {
+			return nil, fmt.Errorf("signoz: invalid parser.pattern: %w", err)
+		}
+	} else if parser == parserRegex {
+		return nil, errors.New("signoz: parser regex needs parser.pattern")
+	}
+
+	multiline, err := newMultilineConfig(route)
+	if err != nil {
+		return nil, err
+	}
+
+	minSeverity, err := parseSeverity(getopt(route, "min_severity", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps, err := newTimestampParser(route)
+	if err != nil {
+		return nil, err
+	}
+	maxTimestampSkew, err := getoptDuration(route, "timestamp.max_skew", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse filter parameters from route.Address
 	filterName := route.Options["filter.name"]
 	filterID := route.Options["filter.id"]
@@ -93,6 +291,28 @@ func NewSignozAdapter(route *router.Route) (router.LogAdapter, error) {
 
 	return &Adapter{
 		route:                   route,
+		exporter:                exp,
+		retry:                   retry,
+		queue:                   queue,
+		buffer:                  buffer,
+		batch:                   batch,
+		deadLetters:             deadLetters,
+		maxRecordBytes:          maxRecordBytes,
+		shutdownTimeout:         shutdownTimeout,
+		workers:                 workers,
+		minSeverity:             minSeverity,
+		parser:                  parser,
+		jsonFields:              newJSONFields(route),
+		timestamps:              timestamps,
+		locations:               make(map[string]*time.Location),
+		maxTimestampSkew:        maxTimestampSkew,
+		pattern:                 pattern,
+		patterns:                make(map[string]*regexParser),
+		multiline:               multiline,
+		warned:                  make(map[string]bool),
+		stopping:                make(chan struct{}),
+		stopped:                 make(chan struct{}),
+		intakeDone:              make(chan struct{}),
 		autoParseJson:           autoParseJson,
 		autoLogLevelStringMatch: autoLogLevelStringMatch,
 		env:                     envValue,
@@ -103,10 +323,89 @@ func NewSignozAdapter(route *router.Route) (router.LogAdapter, error) {
 	}, nil
 }
 
+// newExporter returns the exporter sending to the collectors of route: one per endpoint,
+// balanced when there are several, behind the circuit breaker.
+func newExporter(route *router.Route) (exporter, error) {
+	protocol, endpoints, err := endpointsFromRoute(route)
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := newCompression(route)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := newHeaders(route)
+	if err != nil {
+		return nil, err
+	}
+
+	exporters := make([]exporter, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		tlsConfig, err := newTLSConfig(route, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		client, err := newHTTPClient(route, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		var exp exporter
+		switch protocol {
+		case protocolOTLPHTTP:
+			exp = &otlpHTTPExporter{&httpSender{endpoint: endpoint, client: client, headers: headers, compression: compression}}
+		case protocolOTLPGRPC:
+			exp, err = newGRPCExporter(endpoint, tlsConfig, headers, compression)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			exp = &jsonExporter{&httpSender{endpoint: endpoint, client: client, headers: headers, compression: compression}}
+		}
+		exporters = append(exporters, exp)
+	}
+
+	exp := exporters[0]
+	if len(exporters) > 1 {
+		exp, err = newBalancer(route, endpoints, exporters)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newCircuitBreaker(route, exp)
+}
+
 // Adapter is a simple adapter that streams log output to a connection without any templating
 type Adapter struct {
 	//conn  net.Conn
 	route                   *router.Route
+	exporter                exporter
+	retry                   retryConfig
+	queue                   *diskQueue
+	buffer                  *logBuffer
+	batch                   batchConfig
+	deadLetters             *deadLetterSink
+	maxRecordBytes          int64
+	shutdownTimeout         time.Duration
+	workers                 int
+	pool                    *exportPool
+	minSeverity             int
+	parser                  string
+	jsonFields              jsonFields
+	timestamps              *timestampParser
+	locations               map[string]*time.Location
+	maxTimestampSkew        time.Duration
+	pattern                 *regexParser
+	patterns                map[string]*regexParser
+	multiline               multilineConfig
+	warned                  map[string]bool
+	stopOnce                sync.Once
+	stopping                chan struct{}
+	stopped                 chan struct{}
+	intakeDone              chan struct{}
+	discarded               atomic.Int64
 	autoParseJson           bool
 	autoLogLevelStringMatch bool
 	env                     string
@@ -117,43 +416,66 @@ type Adapter struct {
 }
 
 type LogMessage struct {
-	Timestamp int `json:"timestamp"`
-	//TraceID        string            `json:"trace_id"`
-	//SpanID         string            `json:"span_id"`
+	Timestamp int64  `json:"timestamp"` // Unix time in nanoseconds
+	TraceID   string `json:"trace_id,omitempty"`
+	SpanID    string `json:"span_id,omitempty"`
 	//TraceFlags     int               `json:"trace_flags"`
 	SeverityText   string            `json:"severity_text"`
 	SeverityNumber int               `json:"severity_number"`
 	Attributes     map[string]string `json:"attributes"`
 	Resources      map[string]string `json:"resources"`
 	Message        string            `json:"message"`
+	containerID    string
 }
 
 func (a *Adapter) Stream(logStream chan *router.Message) {
-	var buffer []LogMessage
-	var mu sync.Mutex
-	ticker := time.NewTicker(5 * time.Second)
-	//defer ticker.Stop()
+	ticker := time.NewTicker(a.batch.timeout)
+	defer ticker.Stop()
+	flushNow := make(chan struct{}, 1)
+
+	registerAdapter(a)
+	defer unregisterAdapter(a)
+
+	a.pool = newExportPool(a.workers, a.buffer.maxRecords, a.exportRecords)
 
 	go func() {
-		for range ticker.C {
-			var temp []LogMessage
-			mu.Lock()
-			if len(buffer) > 0 {
-				temp = append(temp, buffer...)
-				buffer = []LogMessage{}
+		defer close(a.stopped)
+		// early is nil while records wait in the buffer for a busy worker, so a full buffer
+		// is not taken and put back on every record added.
+		early := flushNow
+		for {
+			stopping := false
+			select {
+			case <-ticker.C:
+			case <-early:
+				ticker.Reset(a.batch.timeout)
+			case <-a.stopping:
+				stopping = true
+			}
+			if stopping {
+				a.finalFlush()
+				return
+			}
+			temp, dropped := a.buffer.take()
+			if dropped > 0 {
+				log.Printf("Buffer full, dropped %d log records (policy %s)", dropped, a.buffer.policy)
 			}
-			mu.Unlock()
-			if len(temp) > 0 {
-				err := sendLogs(temp)
-				if err != nil {
-					log.Println("Error sending logs:", err)
-				}
+			// The records of a container whose worker is still busy wait in the buffer, so
+			// they are bounded by it while the other containers keep flowing.
+			early = flushNow
+			if busy := a.pool.dispatch(temp); len(busy) > 0 {
+				a.buffer.requeue(busy)
+				early = nil
 			}
 		}
 	}()
 
+	if a.queue != nil {
+		go a.drainQueue()
+	}
+
 	var logMessage LogMessage
-	for message := range logStream {
+	for message := range a.multiline.aggregate(logStream, a.stopping) {
 
 		// Apply filters
 		if !a.shouldProcessMessage(message) {
@@ -171,7 +493,7 @@ func (a *Adapter) Stream(logStream chan *router.Message) {
 			serviceName = serviceNameFromSwarmLabel
 		}
 		logMessage = LogMessage{
-			Timestamp: int(message.Time.Unix()),
+			Timestamp: message.Time.UnixNano(),
 			//TraceID:        "0", // replace with actual data
 			//SpanID:         "0", // replace with actual data
 			//TraceFlags:     0,   // replace with actual data
@@ -181,108 +503,221 @@ func (a *Adapter) Stream(logStream chan *router.Message) {
 			Resources: map[string]string{
 				"service.name": serviceName,
 			},
-			Message: message.Data,
+			Message:     message.Data,
+			containerID: message.Container.ID,
 		}
 		if a.env != "" {
 			logMessage.Resources["deployment.environment"] = a.env
 		}
 
-		jsonInterface := parseJSON(message.Data)
-		if jsonInterface != nil {
-			if jsonMap, ok := jsonInterface.(map[string]interface{}); ok {
-				if jsonMap["timestamp"] != nil {
-					if timestampStr, ok := jsonMap["timestamp"].(string); ok {
-						timestamp, err := time.Parse(time.RFC3339, timestampStr)
-						if err == nil {
-							logMessage.Timestamp = int(timestamp.Unix())
-						}
-					}
-				}
-
-				if jsonMap["level"] != nil {
-					if levelStr, ok := jsonMap["level"].(string); ok {
-						level = levelStr
-						leverNumber := logLevelMap[strings.ToUpper(level)]
-						logMessage.SeverityText = level
-						logMessage.SeverityNumber = leverNumber
-					}
-				}
-
-				if jsonMap["message"] != nil {
-					if messageStr, ok := jsonMap["message"].(string); ok {
-						logMessage.Message = messageStr
-					}
-				}
-
-				if jsonMap["env"] != nil {
-					if envStr, ok := jsonMap["env"].(string); ok {
-						logMessage.Resources["deployment.environment"] = envStr
-					}
-				}
-				if jsonMap["environment"] != nil {
-					if envStr, ok := jsonMap["environment"].(string); ok {
-						logMessage.Resources["deployment.environment"] = envStr
-					}
-				}
-
-				if jsonMap["service"] != nil {
-					if serviceStr, ok := jsonMap["service"].(string); ok {
-						logMessage.Resources["service.name"] = serviceStr
-					}
-				}
-				if jsonMap["namespace"] != nil {
-					if namespaceStr, ok := jsonMap["namespace"].(string); ok {
-						logMessage.Resources["namespace"] = namespaceStr
-					}
-				}
-				// Get loop through non standard keys and save them as attributes inside logMessage
-				for key, value := range jsonMap {
-					if !contains(standardJsonAttributeKeys, key) {
-						logMessage.Attributes[key] = fmt.Sprintf("%v", value)
-					}
-				}
+		parser, lineParser := a.parserFor(message.Container)
+		a.parse(parser, lineParser, a.jsonFieldsFor(message.Container), a.timestampParserFor(message.Container), message.Data, &logMessage)
+		a.checkTimestamp(&logMessage, message.Time)
+
+		if logMessage.SeverityNumber < a.minSeverity {
+			continue
+		}
+
+		// Add log to buffer and flush early once a batch is full
+		if a.batch.reached(a.buffer.add(logMessage)) {
+			select {
+			case flushNow <- struct{}{}:
+			default:
 			}
-		} else {
-			if a.autoLogLevelStringMatch {
-				for level, number := range logLevelMap {
-					if strings.Contains(message.Data, level) {
-						logMessage.SeverityText = strings.ToLower(level)
-						logMessage.SeverityNumber = number
-						break
-					}
-				}
+		}
+	}
+	close(a.intakeDone)
+
+	// Once stopping, lines logged while the final flush runs are discarded, but logspout must
+	// not block on the stream.
+	for range logStream {
+		a.discarded.Add(1)
+	}
+	a.stop()
+}
+
+// exportRecords splits records into batches and flushes them. While the circuit breaker is
+// open it waits for the cool-down and tries the same batch again, so later records of the
+// same containers cannot overtake it. Once the adapter is stopping it returns the records not
+// sent yet instead.
+func (a *Adapter) exportRecords(records []LogMessage) []LogMessage {
+	batches := a.batch.split(records)
+	for i := 0; i < len(batches); {
+		err := a.flush(batches[i])
+		if errors.Is(err, errCircuitOpen) {
+			if !sleep(retryAfter(err), a.stopping) {
+				return slices.Concat(batches[i:]...)
 			}
+			continue
 		}
+		if err != nil {
+			log.Println("Error sending logs:", err)
+		}
+		i++
+	}
+	return nil
+}
 
-		mu.Lock()
-		buffer = append(buffer, logMessage) // Add log to buffer
-		mu.Unlock()
+// flush hands a batch to the disk queue when one is configured and sends it directly otherwise.
+func (a *Adapter) flush(logs []LogMessage) error {
+	if a.queue != nil {
+		err := a.queue.push(logs)
+		if err == nil {
+			return nil
+		}
+		log.Println("Error queueing logs, sending directly:", err)
 	}
+
+	return a.send(logs, true)
 }
 
-func sendLogs(logs []LogMessage) error {
-	// Convert logs to JSON
-	data, err := json.Marshal(logs)
-	if err != nil {
-		return err
+// send exports a batch with deliver. Records that fail permanently go to the dead-letter sink,
+// and so do records that fail transiently when final is set. Records refused by the open
+// circuit breaker are left to the caller.
+func (a *Adapter) send(logs []LogMessage, final bool) error {
+	var errs []error
+	a.deliver(logs, func(failed []LogMessage, err error) bool {
+		if !errors.Is(err, errCircuitOpen) && (final || !isRetryable(err)) {
+			a.deadLetters.write(failed, err)
+		}
+		errs = append(errs, err)
+		return true
+	})
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// deliver exports a batch with retries. A batch rejected as too large is split in half and the
+// halves are delivered on their own, down to single records, which are truncated once before
+// they are given up on. Every part given up on is passed to failed with its error, and delivery
+// stops when failed returns false. deliver returns the number of records handled before that,
+// and whether it went through the whole batch.
+func (a *Adapter) deliver(logs []LogMessage, failed func([]LogMessage, error) bool) (int, bool) {
+	err := a.retry.do(a.stopping, func() error { return a.exporter.export(logs) })
+	var exportErr *exportError
+	if errors.As(err, &exportErr) && exportErr.statusCode == http.StatusRequestEntityTooLarge {
+		if len(logs) > 1 {
+			metrics.Add("batches_split", 1)
+			half := len(logs) / 2
+			n, ok := a.deliver(logs[:half], failed)
+			if !ok {
+				return n, false
+			}
+			m, ok := a.deliver(logs[half:], failed)
+			return n + m, ok
+		}
+
+		if truncated, ok := truncateRecord(logs[0], a.maxRecordBytes); ok {
+			metrics.Add("records_truncated", 1)
+			log.Printf("Log record too large for the collector, truncating its message to %d bytes", a.maxRecordBytes)
+			err = a.retry.do(a.stopping, func() error { return a.exporter.export([]LogMessage{truncated}) })
+		}
+	}
+
+	if err != nil && !failed(logs, err) {
+		return 0, false
+	}
+	return len(logs), true
+}
+
+// truncateRecord shortens the message of a record to maxBytes. It returns false when the
+// message is already short enough, so truncating would not help.
+func truncateRecord(m LogMessage, maxBytes int64) (LogMessage, bool) {
+	const marker = "...[truncated]"
+	if maxBytes <= int64(len(marker)) || int64(len(m.Message)) <= maxBytes {
+		return m, false
+	}
+	cut := int(maxBytes) - len(marker)
+	for cut > 0 && !utf8.RuneStart(m.Message[cut]) {
+		cut--
+	}
+	m.Message = m.Message[:cut] + marker
+	return m, true
+}
+
+// drainQueue sends queued batches oldest first. A batch is acknowledged once the collector
+// accepted it or rejected it permanently, in which case it went to the dead-letter sink; after
+// a transient failure or while the circuit breaker is open it stays queued and is tried again.
+func (a *Adapter) drainQueue() {
+	for {
+		logs, err := a.queue.peek()
+		if err != nil {
+			return
+		}
+
+		err = a.send(logs, false)
+		if isRetryable(err) {
+			wait := a.retry.maxInterval
+			if pause := retryAfter(err); pause > 0 {
+				wait = pause
+			}
+			log.Printf("Error sending queued logs, keeping them queued and retrying in %s: %v", wait.Round(time.Millisecond), err)
+			if !sleep(wait, a.stopping) {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			log.Println("Error sending logs:", err)
+		}
+		if err := a.queue.ack(); err != nil {
+			log.Println("Error acknowledging queued logs:", err)
+		}
 	}
+}
+
+// exporter delivers a batch of log messages to a collector.
+type exporter interface {
+	export(logs []LogMessage) error
+}
+
+// exportError is returned by exporters when the collector rejected a batch. retryable tells
+// whether sending the same batch again may succeed, statusCode is the HTTP status if any,
+// response an excerpt of the response body and retryAfter the wait the collector asked for
+// before the next attempt.
+type exportError struct {
+	err        error
+	retryable  bool
+	statusCode int
+	response   string
+	retryAfter time.Duration
+}
+
+func (e *exportError) Error() string {
+	return e.err.Error()
+}
 
-	signozLogEndpoint := os.Getenv("SIGNOZ_LOG_ENDPOINT")
-	if signozLogEndpoint == "" {
-		signozLogEndpoint = "http://localhost:8082"
+func (e *exportError) Unwrap() error {
+	return e.err
+}
+
+// parseHeaders parses a list of key:value pairs, the same format used by filter.labels.
+func parseHeaders(headersStr string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range splitList(headersStr) {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) == 2 {
+			headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
 	}
+	return headers
+}
+
+// jsonExporter posts batches in the format accepted by the SigNoz httplogreceiver/json receiver.
+type jsonExporter struct {
+	*httpSender
+}
 
-	// Send HTTP POST request
-	fmt.Println("Sending logs to: ", signozLogEndpoint)
-	resp, err := http.Post(signozLogEndpoint, "application/json", bytes.NewBuffer(data))
+func (e *jsonExporter) export(logs []LogMessage) error {
+	// Convert logs to JSON
+	data, err := json.Marshal(logs)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to send logs, status: %s", resp.Status)
-	}
-	return nil
+	_, err = e.post("application/json", data)
+	return err
 }
 
 // shouldProcessMessage checks if a message should be processed based on filter criteria
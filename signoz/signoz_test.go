@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -440,3 +442,57 @@ func TestContainerFiltering(t *testing.T) {
 		})
 	}
 }
+
+// routeFromURI builds a route from a route URI the way logspout's AddFromURI does: only the
+// host of the URI is kept as the address, and the query parameters become options.
+func routeFromURI(t *testing.T, uri string) *router.Route {
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) error = %v", uri, err)
+	}
+	route := &router.Route{Adapter: u.Scheme, Address: u.Host, Options: map[string]string{}}
+	for key := range u.Query() {
+		route.Options[key] = u.Query().Get(key)
+	}
+	return route
+}
+
+func TestEndpointsFromRoute(t *testing.T) {
+	os.Setenv("SIGNOZ_LOG_ENDPOINT", "http://fallback:8082")
+	defer os.Unsetenv("SIGNOZ_LOG_ENDPOINT")
+
+	tests := []struct {
+		name         string
+		uri          string
+		wantProtocol string
+		want         []string
+		wantErr      bool
+	}{
+		{"No address falls back to env", "signoz://", protocolJSON, []string{"http://fallback:8082"}, false},
+		{"Plain signoz scheme", "signoz://collector:8082", protocolJSON, []string{"http://collector:8082"}, false},
+		{"Explicit http transport", "signoz+http://collector:8082", protocolJSON, []string{"http://collector:8082"}, false},
+		{"Https transport", "signoz+https://collector:443", protocolJSON, []string{"https://collector:443"}, false},
+		{"Path in the URI is dropped", "signoz+https://collector/logs/json", protocolJSON, []string{"https://collector"}, false},
+		{"Path option", "signoz+https://collector?path=/logs/json", protocolJSON, []string{"https://collector/logs/json"}, false},
+		{"Path option with query", "signoz+https://collector?path=%2Flogs%2Fjson%3Ftenant%3Da", protocolJSON, []string{"https://collector/logs/json?tenant=a"}, false},
+		{"OTLP adds default path", "signoz+otlp://collector:4318", protocolOTLPHTTP, []string{"http://collector:4318/v1/logs"}, false},
+		{"OTLP over https with path option", "signoz+otlp+https://collector?path=/otlp/v1/logs", protocolOTLPHTTP, []string{"https://collector/otlp/v1/logs"}, false},
+		{"Additional endpoints", "signoz+otlp://c1:4318?endpoints=c2:4318|c3:4318", protocolOTLPHTTP, []string{"http://c1:4318/v1/logs", "http://c2:4318/v1/logs", "http://c3:4318/v1/logs"}, false},
+		{"Unknown transport", "signoz+udp://collector:8082", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protocol, got, err := endpointsFromRoute(routeFromURI(t, tt.uri))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("endpointsFromRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if protocol != tt.wantProtocol {
+				t.Errorf("endpointsFromRoute() protocol = %q; want %q", protocol, tt.wantProtocol)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("endpointsFromRoute() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package signoz
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+const (
+	// timestampLayoutLabel sets a custom layout for the timestamps in the logs of a container.
+	timestampLayoutLabel = "signoz.timestamp.layout"
+	// timestampTimezoneLabel sets the time zone of timestamps without one for a container.
+	timestampTimezoneLabel = "signoz.timestamp.timezone"
+)
+
+// timestampLayouts are the layouts tried after the custom layout, in order. When parsing, Go
+// accepts fractional seconds after the seconds of any layout, introduced by . or , as in
+// 2006-01-02 15:04:05,000.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700", // ISO 8601 with a basic offset, e.g. +0200
+	"2006-01-02T15:04:05",      // ISO 8601 without a zone
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 Z0700",
+	"2006-01-02 15:04:05",        // log4j, Python logging
+	"2006/01/02 15:04:05",        // Go log package
+	"02/Jan/2006:15:04:05 -0700", // Common Log Format
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// minTimestamp and maxTimestamp bound the times that fit into LogMessage.Timestamp.
+var (
+	minTimestamp = time.Unix(0, math.MinInt64)
+	maxTimestamp = time.Unix(0, math.MaxInt64)
+)
+
+// timestampParser reads the timestamps found in log lines. layout, if set, is tried first, and
+// location is used for timestamps that don't name a zone.
+type timestampParser struct {
+	layout   string
+	location *time.Location
+}
+
+// defaultTimestampParser detects timestamps with the built-in layouts in UTC.
+var defaultTimestampParser = &timestampParser{location: time.UTC}
+
+// newTimestampParser returns the timestamp parser set by the timestamp.layout and
+// timestamp.timezone options of route.
+func newTimestampParser(route *router.Route) (*timestampParser, error) {
+	location, err := loadLocation(getopt(route, "timestamp.timezone", "UTC"))
+	if err != nil {
+		return nil, fmt.Errorf("signoz: invalid timestamp.timezone: %w", err)
+	}
+	return &timestampParser{layout: getopt(route, "timestamp.layout", ""), location: location}, nil
+}
+
+// loadLocation returns the time zone name, which is either a zone of the IANA database such as
+// Europe/Berlin, UTC, Local or a fixed offset such as +02:00.
+func loadLocation(name string) (*time.Location, error) {
+	if offset, err := time.Parse("-07:00", name); err == nil {
+		_, seconds := offset.Zone()
+		return time.FixedZone(name, seconds), nil
+	}
+	return time.LoadLocation(name)
+}
+
+// timestampParserFor returns the timestamp parser of the route with the signoz.timestamp.layout
+// and signoz.timestamp.timezone labels of container applied.
+func (a *Adapter) timestampParserFor(container *docker.Container) *timestampParser {
+	labels := container.Config.Labels
+	layout, hasLayout := labels[timestampLayoutLabel]
+	name, hasTimezone := labels[timestampTimezoneLabel]
+	if !hasLayout && !hasTimezone {
+		return a.timestamps
+	}
+
+	p := *a.timestamps
+	if hasLayout {
+		p.layout = layout
+	}
+	if hasTimezone {
+		location, loaded := a.locations[name]
+		if !loaded {
+			var err error
+			if location, err = loadLocation(name); err != nil {
+				a.warn(container, "Invalid %s on container %s: %v", timestampTimezoneLabel, container.Name, err)
+			}
+			store(a.locations, name, location)
+		}
+		if location != nil {
+			p.location = location
+		}
+	}
+	return &p
+}
+
+// parse reads a timestamp: an epoch number in seconds, milliseconds, microseconds or
+// nanoseconds, or a time in the custom layout or one of timestampLayouts. A nil parser uses
+// defaultTimestampParser.
+func (p *timestampParser) parse(value string) (time.Time, bool) {
+	if p == nil {
+		p = defaultTimestampParser
+	}
+	value = strings.TrimSpace(value)
+	timestamp, ok := p.parseLayouts(value)
+	if !ok || timestamp.Before(minTimestamp) || timestamp.After(maxTimestamp) {
+		return time.Time{}, false
+	}
+	return timestamp, true
+}
+
+func (p *timestampParser) parseLayouts(value string) (time.Time, bool) {
+	if p.layout != "" {
+		if timestamp, err := time.ParseInLocation(p.layout, value, p.location); err == nil {
+			return timestamp, true
+		}
+	}
+	if timestamp, ok := parseEpoch(value); ok {
+		return timestamp, true
+	}
+	for _, layout := range timestampLayouts {
+		if timestamp, err := time.ParseInLocation(layout, value, p.location); err == nil {
+			return timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseEpoch reads a number of seconds, milliseconds, microseconds or nanoseconds since the Unix
+// epoch, possibly with a fraction. The unit is told by the magnitude: up to 11 digits are
+// seconds, which lasts until the year 5138, up to 14 milliseconds and up to 17 microseconds.
+func parseEpoch(value string) (time.Time, bool) {
+	integer, fraction, _ := strings.Cut(value, ".")
+	if !isDigits(integer) || (fraction != "" && !isDigits(fraction)) {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var unit int64 // nanoseconds
+	switch {
+	case n < 1e11:
+		unit = 1e9
+	case n < 1e14:
+		unit = 1e6
+	case n < 1e17:
+		unit = 1e3
+	default:
+		unit = 1
+	}
+	perSecond := int64(1e9) / unit
+
+	// The fraction of one unit, in nanoseconds.
+	if len(fraction) > 9 {
+		fraction = fraction[:9]
+	}
+	var nanos int64
+	if fraction != "" {
+		nanos, _ = strconv.ParseInt(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+	}
+	return time.Unix(n/perSecond, n%perSecond*unit+nanos*unit/1e9), true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkTimestamp falls back to the time Docker received the line when the timestamp taken from
+// the line is further than timestamp.max_skew from it, e.g. because it was misparsed.
+func (a *Adapter) checkTimestamp(logMessage *LogMessage, received time.Time) {
+	if a.maxTimestampSkew <= 0 {
+		return
+	}
+	skew := time.Unix(0, logMessage.Timestamp).Sub(received)
+	if skew > a.maxTimestampSkew || skew < -a.maxTimestampSkew {
+		logMessage.Timestamp = received.UnixNano()
+		metrics.Add("timestamp_rejected", 1)
+	}
+}
@@ -0,0 +1,110 @@
+package signoz
+
+import (
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestTimestampParserParse(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	tests := []struct {
+		parser *timestampParser
+		value  string
+		want   time.Time
+	}{
+		{nil, "1714557600", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{nil, "1714557600.123456", time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC)},
+		{nil, "1714557600123", time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC)},
+		{nil, "1714557600123456", time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC)},
+		{nil, "1714557600123456789", time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)},
+		{nil, "2024-05-01T10:00:00.123456789Z", time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)},
+		{nil, "2024-05-01T12:00:00+02:00", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{nil, "2024-05-01T12:00:00.5+0200", time.Date(2024, 5, 1, 10, 0, 0, 500000000, time.UTC)},
+		{nil, "2024-05-01T10:00:00.250", time.Date(2024, 5, 1, 10, 0, 0, 250000000, time.UTC)},
+		{nil, "2024-05-01 10:00:00,123", time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC)},
+		{nil, "2024/05/01 10:00:00", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{nil, "01/May/2024:12:00:00 +0200", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{&timestampParser{location: berlin}, "2024-05-01 12:00:00", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{&timestampParser{location: berlin}, "2024-05-01T10:00:00Z", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{&timestampParser{layout: "02.01.2006 15:04:05", location: time.UTC}, "01.05.2024 10:00:00", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{&timestampParser{layout: "20060102150405", location: time.UTC}, "20240501100000", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := tt.parser.parse(tt.value)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("parse(%q) = %v, %v; want %v", tt.value, got, ok, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "-1714557600", "1714557600.x", "99999999999999999999", "2024-13-01T00:00:00Z"} {
+		if got, ok := defaultTimestampParser.parse(value); ok {
+			t.Errorf("parse(%q) = %v; want no timestamp", value, got)
+		}
+	}
+}
+
+func TestTimestampParserFor(t *testing.T) {
+	a, err := newAdapter(&router.Route{Address: "collector:8082", Options: map[string]string{"timestamp.timezone": "+02:00"}})
+	if err != nil {
+		t.Fatalf("newAdapter() error = %v", err)
+	}
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	container := &docker.Container{ID: "abc", Config: &docker.Config{}}
+	if got, _ := a.timestampParserFor(container).parse("2024-05-01 12:00:00"); !got.Equal(want) {
+		t.Errorf("route time zone: parse() = %v; want %v", got, want)
+	}
+
+	container.Config.Labels = map[string]string{timestampLayoutLabel: "Jan 2 2006 15:04", timestampTimezoneLabel: "-01:00"}
+	if got, _ := a.timestampParserFor(container).parse("May 1 2024 09:00"); !got.Equal(want) {
+		t.Errorf("label layout and time zone: parse() = %v; want %v", got, want)
+	}
+
+	container.Config.Labels = map[string]string{timestampTimezoneLabel: "Nowhere/Special"}
+	if got, _ := a.timestampParserFor(container).parse("2024-05-01 12:00:00"); !got.Equal(want) {
+		t.Errorf("invalid label: parse() = %v; want the route time zone", got)
+	}
+
+	if _, err := newAdapter(&router.Route{Address: "collector:8082", Options: map[string]string{"timestamp.timezone": "Nowhere/Special"}}); err == nil {
+		t.Error("newAdapter() with an invalid timestamp.timezone succeeded")
+	}
+}
+
+func TestCheckTimestamp(t *testing.T) {
+	received := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	a := &Adapter{maxTimestampSkew: time.Hour}
+	tests := []struct {
+		timestamp time.Time
+		want      time.Time
+	}{
+		{received.Add(-59 * time.Minute), received.Add(-59 * time.Minute)},
+		{received.Add(2 * time.Hour), received},
+		{time.Unix(0, 0), received},
+	}
+	for _, tt := range tests {
+		logMessage := LogMessage{Timestamp: tt.timestamp.UnixNano()}
+		a.checkTimestamp(&logMessage, received)
+		if logMessage.Timestamp != tt.want.UnixNano() {
+			t.Errorf("checkTimestamp(%v) = %v; want %v", tt.timestamp, time.Unix(0, logMessage.Timestamp).UTC(), tt.want)
+		}
+	}
+}
+
+func TestParseJSONMessageEpochTimestamp(t *testing.T) {
+	data := `{"ts":1714557600123456789,"msg":"hello","count":1e3}`
+	logMessage := newTestParseMessage(data)
+	(&Adapter{}).parseJSONMessage(data, nil, nil, &logMessage)
+
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC).UnixNano(); logMessage.Timestamp != want {
+		t.Errorf("Timestamp = %d; want %d", logMessage.Timestamp, want)
+	}
+	if logMessage.Attributes["count"] != "1e3" {
+		t.Errorf("Attributes = %v; want count as written", logMessage.Attributes)
+	}
+}
@@ -0,0 +1,177 @@
+package signoz
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig returns the TLS client configuration for connections of route to endpoint, or
+// nil when no TLS option is set and the defaults apply. The CA bundle and client certificate
+// are reloaded when the files change on disk, so rotated certificates are picked up without a
+// restart.
+func newTLSConfig(route *router.Route, endpoint string) (*tls.Config, error) {
+	reloader := &certReloader{
+		caFile:   getopt(route, "tls.ca_file", ""),
+		certFile: getopt(route, "tls.cert_file", ""),
+		keyFile:  getopt(route, "tls.key_file", ""),
+	}
+	minVersion := getopt(route, "tls.min_version", "")
+	serverName := getopt(route, "tls.server_name", "")
+	insecureSkipVerify := getopt(route, "tls.insecure_skip_verify", "") == "true"
+
+	if reloader.caFile == "" && reloader.certFile == "" && reloader.keyFile == "" &&
+		minVersion == "" && serverName == "" && !insecureSkipVerify {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: insecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if minVersion != "" {
+		version, ok := tlsVersions[minVersion]
+		if !ok {
+			return nil, fmt.Errorf("signoz: invalid tls.min_version %q", minVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if (reloader.certFile == "") != (reloader.keyFile == "") {
+		return nil, fmt.Errorf("signoz: tls.cert_file and tls.key_file must be set together")
+	}
+	if reloader.certFile != "" {
+		if _, err := reloader.clientCertificate(nil); err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = reloader.clientCertificate
+	}
+
+	if reloader.caFile != "" && !insecureSkipVerify {
+		if _, err := reloader.rootCAs(); err != nil {
+			return nil, err
+		}
+		// Verification against the reloadable pool is done in VerifyConnection, because
+		// RootCAs cannot be swapped on a client config. The handshake does not tell the name
+		// to check for IP addresses, as no server name is sent for them, so it is taken from
+		// the endpoint.
+		dnsName := serverName
+		if dnsName == "" {
+			u, err := url.Parse(endpoint)
+			if err != nil {
+				return nil, fmt.Errorf("signoz: invalid endpoint %q: %w", endpoint, err)
+			}
+			dnsName = u.Hostname()
+		}
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = reloader.verifyConnection(dnsName)
+	}
+	return config, nil
+}
+
+// certReloader keeps the CA pool and client certificate in sync with their files.
+type certReloader struct {
+	caFile, certFile, keyFile string
+
+	mu          sync.Mutex
+	caModTime   time.Time
+	pool        *x509.CertPool
+	certModTime time.Time
+	cert        *tls.Certificate
+}
+
+func modTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *certReloader) rootCAs() (*x509.CertPool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mtime, err := modTime(r.caFile)
+	if err != nil {
+		return nil, fmt.Errorf("signoz: cannot read tls.ca_file: %w", err)
+	}
+	if r.pool != nil && mtime.Equal(r.caModTime) {
+		return r.pool, nil
+	}
+
+	pem, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return nil, fmt.Errorf("signoz: cannot read tls.ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("signoz: no certificates found in tls.ca_file %s", r.caFile)
+	}
+	r.pool, r.caModTime = pool, mtime
+	return pool, nil
+}
+
+func (r *certReloader) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mtime, err := modTime(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("signoz: cannot read client certificate: %w", err)
+	}
+	if r.cert != nil && mtime.Equal(r.certModTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("signoz: cannot load client certificate: %w", err)
+	}
+	r.cert, r.certModTime = &cert, mtime
+	return r.cert, nil
+}
+
+// verifyConnection returns a check that the server certificate was issued for dnsName, which
+// may be an IP address, by a CA of the pool.
+func (r *certReloader) verifyConnection(dnsName string) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		pool, err := r.rootCAs()
+		if err != nil {
+			return err
+		}
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("signoz: server sent no certificate")
+		}
+		opts := x509.VerifyOptions{
+			Roots:         pool,
+			DNSName:       dnsName,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range state.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err = state.PeerCertificates[0].Verify(opts)
+		return err
+	}
+}
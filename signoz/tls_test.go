@@ -0,0 +1,189 @@
+package signoz
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert creates a certificate for commonName, a host name or an IP address, signed by
+// parent, or a self-signed CA when parent is nil.
+func newTestCert(t *testing.T, parent *testCert, commonName string) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ip := net.ParseIP(commonName); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{commonName}
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	if config, err := newTLSConfig(&router.Route{}, "https://collector"); config != nil || err != nil {
+		t.Errorf("newTLSConfig() without options = %v, %v; want nil, nil", config, err)
+	}
+
+	config, err := newTLSConfig(&router.Route{Options: map[string]string{"tls.min_version": "1.3", "tls.server_name": "collector"}}, "https://collector")
+	if err != nil {
+		t.Fatalf("newTLSConfig() error = %v", err)
+	}
+	if config.MinVersion != tls.VersionTLS13 || config.ServerName != "collector" {
+		t.Errorf("newTLSConfig() = %+v", config)
+	}
+
+	invalid := []map[string]string{
+		{"tls.min_version": "2.0"},
+		{"tls.cert_file": "cert.pem"},
+		{"tls.ca_file": "/does/not/exist"},
+	}
+	for _, options := range invalid {
+		if _, err := newTLSConfig(&router.Route{Options: options}, "https://collector"); err == nil {
+			t.Errorf("newTLSConfig(%v) error = nil; want error", options)
+		}
+	}
+}
+
+func TestMutualTLSExport(t *testing.T) {
+	ca := newTestCert(t, nil, "test-ca")
+	serverCert := newTestCert(t, ca, "collector.internal")
+	clientCert := newTestCert(t, ca, "logspout")
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "logspout" {
+			t.Errorf("Expected client certificate logspout")
+		}
+	}))
+	tlsCert, _ := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{tlsCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	os.WriteFile(caFile, newTestCert(t, nil, "other-ca").certPEM, 0o600)
+	os.WriteFile(filepath.Join(dir, "client.pem"), clientCert.certPEM, 0o600)
+	os.WriteFile(filepath.Join(dir, "client.key"), clientCert.keyPEM, 0o600)
+
+	config, err := newTLSConfig(&router.Route{Options: map[string]string{
+		"tls.ca_file":     caFile,
+		"tls.cert_file":   filepath.Join(dir, "client.pem"),
+		"tls.key_file":    filepath.Join(dir, "client.key"),
+		"tls.server_name": "collector.internal",
+	}}, server.URL)
+	if err != nil {
+		t.Fatalf("newTLSConfig() error = %v", err)
+	}
+
+	client, err := newHTTPClient(&router.Route{}, config)
+	if err != nil {
+		t.Fatalf("newHTTPClient() error = %v", err)
+	}
+	exporter := &jsonExporter{&httpSender{endpoint: server.URL, client: client}}
+	if err := exporter.export([]LogMessage{{Message: "hello"}}); err == nil {
+		t.Fatal("export() with the wrong CA succeeded; want error")
+	}
+
+	// Replacing the CA file on disk is picked up by the next connection.
+	os.WriteFile(caFile, ca.certPEM, 0o600)
+	os.Chtimes(caFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	client.CloseIdleConnections()
+	if err := exporter.export([]LogMessage{{Message: "hello"}}); err != nil {
+		t.Fatalf("export() after CA reload error = %v", err)
+	}
+}
+
+func TestTLSVerifiesEndpointName(t *testing.T) {
+	ca := newTestCert(t, nil, "test-ca")
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, ca.certPEM, 0o600)
+
+	tests := []struct {
+		name       string
+		serverCert *testCert
+		maxVersion uint16
+		wantErr    bool
+	}{
+		{"IP address TLS 1.2", newTestCert(t, ca, "127.0.0.1"), tls.VersionTLS12, false},
+		{"IP address TLS 1.3", newTestCert(t, ca, "127.0.0.1"), tls.VersionTLS13, false},
+		{"Other name TLS 1.2", newTestCert(t, ca, "other.example"), tls.VersionTLS12, true},
+		{"Other name TLS 1.3", newTestCert(t, ca, "other.example"), tls.VersionTLS13, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			tlsCert, _ := tls.X509KeyPair(tt.serverCert.certPEM, tt.serverCert.keyPEM)
+			server.TLS = &tls.Config{Certificates: []tls.Certificate{tlsCert}, MaxVersion: tt.maxVersion}
+			server.StartTLS()
+			defer server.Close()
+
+			config, err := newTLSConfig(&router.Route{Options: map[string]string{"tls.ca_file": caFile}}, server.URL)
+			if err != nil {
+				t.Fatalf("newTLSConfig() error = %v", err)
+			}
+			client, err := newHTTPClient(&router.Route{}, config)
+			if err != nil {
+				t.Fatalf("newHTTPClient() error = %v", err)
+			}
+			exporter := &jsonExporter{&httpSender{endpoint: server.URL, client: client}}
+			if err := exporter.export([]LogMessage{{Message: "hello"}}); (err != nil) != tt.wantErr {
+				t.Errorf("export() error = %v; want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
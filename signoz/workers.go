@@ -0,0 +1,141 @@
+package signoz
+
+import (
+	"hash/fnv"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// exportPool sends records from several goroutines at once. Records are sharded by container
+// ID and every shard is served by a single worker, so the records of one container still reach
+// the collector in the order they were logged.
+type exportPool struct {
+	shards []*shard
+	// maxQueued bounds the records queued on a shard, when positive.
+	maxQueued int
+	held      [][]LogMessage
+	wg        sync.WaitGroup
+	// inFlight counts the records dispatched and not handled by send yet, including those
+	// the workers hold.
+	inFlight atomic.Int64
+}
+
+// shard queues the parts dispatched to a worker while it is busy, so a worker stuck in a retry
+// does not hold up dispatch to the others.
+type shard struct {
+	mu      sync.Mutex
+	queued  [][]LogMessage
+	records int
+	closed  bool
+	ready   chan struct{}
+}
+
+func (s *shard) notify() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the oldest queued part, or nil when there is none. closed reports whether the
+// shard was closed and nothing is left.
+func (s *shard) next() (records []LogMessage, closed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queued) == 0 {
+		return nil, s.closed
+	}
+	records = s.queued[0]
+	s.queued[0] = nil
+	s.queued = s.queued[1:]
+	s.records -= len(records)
+	return records, false
+}
+
+// newExportPool starts workers goroutines that hand the records dispatched to them to send.
+// send returns the records it could not send yet. The worker holds them and hands them to send
+// again in front of the records dispatched next, so they are not overtaken. At most maxQueued
+// records wait for a busy worker, or any number when maxQueued is zero.
+func newExportPool(workers, maxQueued int, send func([]LogMessage) []LogMessage) *exportPool {
+	p := &exportPool{shards: make([]*shard, max(workers, 1)), maxQueued: maxQueued}
+	p.held = make([][]LogMessage, len(p.shards))
+	for i := range p.shards {
+		s := &shard{ready: make(chan struct{}, 1)}
+		p.shards[i] = s
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			var held []LogMessage
+			for range s.ready {
+				for {
+					records, closed := s.next()
+					if closed {
+						p.held[i] = held
+						return
+					}
+					if records == nil {
+						break
+					}
+					pending := append(held, records...)
+					held = send(pending)
+					p.inFlight.Add(int64(len(held) - len(pending)))
+				}
+			}
+		}()
+	}
+	return p
+}
+
+// dispatch partitions records by container and queues each part on its shard, keeping their
+// order. It does not wait for the workers: it returns the parts of the shards that already
+// queue maxQueued records, to be dispatched again later.
+func (p *exportPool) dispatch(records []LogMessage) []LogMessage {
+	parts := [][]LogMessage{records}
+	if len(p.shards) > 1 {
+		parts = make([][]LogMessage, len(p.shards))
+		for _, m := range records {
+			i := shardOf(m.containerID, len(p.shards))
+			parts[i] = append(parts[i], m)
+		}
+	}
+
+	var busy []LogMessage
+	for i, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		s := p.shards[i]
+		s.mu.Lock()
+		if p.maxQueued > 0 && s.records > 0 && s.records+len(part) > p.maxQueued {
+			s.mu.Unlock()
+			busy = append(busy, part...)
+			continue
+		}
+		p.inFlight.Add(int64(len(part)))
+		s.queued = append(s.queued, part)
+		s.records += len(part)
+		s.mu.Unlock()
+		s.notify()
+	}
+	return busy
+}
+
+// close waits until all dispatched records were handed to send, stops the workers and returns
+// the records they still hold, in order per container.
+func (p *exportPool) close() []LogMessage {
+	for _, s := range p.shards {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		s.notify()
+	}
+	p.wg.Wait()
+	return slices.Concat(p.held...)
+}
+
+func shardOf(containerID string, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(containerID))
+	return int(h.Sum32() % uint32(shards))
+}
@@ -0,0 +1,211 @@
+package signoz
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/logspout/router"
+)
+
+func TestExportPoolKeepsContainerOrder(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string][]int)
+	pool := newExportPool(4, 0, func(records []LogMessage) []LogMessage {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range records {
+			n, _ := strconv.Atoi(m.Message)
+			received[m.containerID] = append(received[m.containerID], n)
+		}
+		return nil
+	})
+
+	for i := 0; i < 20; i++ {
+		var records []LogMessage
+		for c := 0; c < 8; c++ {
+			records = append(records, LogMessage{Message: strconv.Itoa(i), containerID: fmt.Sprintf("container-%d", c)})
+		}
+		pool.dispatch(records)
+	}
+	pool.close()
+
+	if len(received) != 8 {
+		t.Fatalf("Got records of %d containers; want 8", len(received))
+	}
+	for container, sequence := range received {
+		if len(sequence) != 20 {
+			t.Errorf("%s: got %d records; want 20", container, len(sequence))
+		}
+		for i, n := range sequence {
+			if n != i {
+				t.Errorf("%s: records out of order: %v", container, sequence)
+				break
+			}
+		}
+	}
+}
+
+func TestExportPoolSendsConcurrently(t *testing.T) {
+	release := make(chan struct{})
+	var inFlight sync.WaitGroup
+	inFlight.Add(2)
+	pool := newExportPool(2, 0, func(records []LogMessage) []LogMessage {
+		inFlight.Done()
+		<-release
+		return nil
+	})
+
+	// Find two containers that land on different shards.
+	first, second := "a", "b"
+	for shardOf(first, 2) == shardOf(second, 2) {
+		second += "b"
+	}
+	pool.dispatch([]LogMessage{{containerID: first}, {containerID: second}})
+
+	done := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected both shards to be sent at the same time")
+	}
+	close(release)
+	pool.close()
+}
+
+func TestExportPoolDoesNotWaitForBusyShard(t *testing.T) {
+	first, second := "a", "b"
+	for shardOf(first, 2) == shardOf(second, 2) {
+		second += "b"
+	}
+
+	busy := make(chan struct{})
+	release := make(chan struct{})
+	sent := make(chan string, 10)
+	pool := newExportPool(2, 1, func(records []LogMessage) []LogMessage {
+		if records[0].Message == "1" {
+			close(busy)
+			<-release
+		}
+		for _, m := range records {
+			sent <- m.Message
+		}
+		return nil
+	})
+
+	pool.dispatch([]LogMessage{{Message: "1", containerID: first}})
+	<-busy
+
+	// The first shard is stuck: one more part is queued for it, the next one is handed back.
+	var returned []LogMessage
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.dispatch([]LogMessage{{Message: "2", containerID: first}})
+		returned = pool.dispatch([]LogMessage{{Message: "3", containerID: first}, {Message: "other", containerID: second}})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatch blocked on a busy shard")
+	}
+	if len(returned) != 1 || returned[0].Message != "3" {
+		t.Errorf("dispatch() = %v; want 3 handed back", returned)
+	}
+	select {
+	case message := <-sent:
+		if message != "other" {
+			t.Errorf("sent %q first; want other", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the other shard to be sent while the first one is busy")
+	}
+
+	close(release)
+	pool.close()
+	var rest []string
+	for len(sent) > 0 {
+		rest = append(rest, <-sent)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("sent %v after release; want %v", rest, want)
+	}
+}
+
+func TestExportPoolKeepsOrderWhileCircuitIsOpen(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []LogMessage
+		json.NewDecoder(r.Body).Decode(&logs)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, logs[0].Message)
+		if len(received) == 1 {
+			// The first batch fails and opens the circuit.
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	a, err := newAdapter(&router.Route{Options: map[string]string{
+		"circuit_breaker.failure_threshold": "1",
+		"circuit_breaker.cool_down":         "50ms",
+		"retry.max_attempts":                "1",
+	}})
+	if err != nil {
+		t.Fatalf("newAdapter() error = %v", err)
+	}
+	a.pool = newExportPool(1, 0, a.exportRecords)
+	for _, message := range []string{"opens", "A", "B"} {
+		a.pool.dispatch([]LogMessage{{Message: message, containerID: "c"}})
+	}
+	if held := a.pool.close(); len(held) != 0 {
+		t.Errorf("close() = %v; want nothing held", held)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"opens", "A", "B"}; !reflect.DeepEqual(received, want) {
+		t.Errorf("received %v; want %v", received, want)
+	}
+}
+
+func TestExportPoolHoldsRecordsOnStop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Setenv("SIGNOZ_LOG_ENDPOINT", server.URL)
+	a, err := newAdapter(&router.Route{Options: map[string]string{
+		"circuit_breaker.failure_threshold": "1",
+		"circuit_breaker.cool_down":         "1h",
+		"retry.max_attempts":                "1",
+	}})
+	if err != nil {
+		t.Fatalf("newAdapter() error = %v", err)
+	}
+	a.pool = newExportPool(1, 0, a.exportRecords)
+	for _, message := range []string{"opens", "A", "B"} {
+		a.pool.dispatch([]LogMessage{{Message: message, containerID: "c"}})
+	}
+	close(a.stopping)
+
+	held := a.pool.close()
+	if len(held) != 2 || held[0].Message != "A" || held[1].Message != "B" {
+		t.Errorf("close() = %v; want A and B in order", held)
+	}
+}